@@ -0,0 +1,195 @@
+package log
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// control holds runtime overrides shared by Logger and all instances derived
+// from it
+type control struct {
+	mu      sync.Mutex
+	current atomic.Pointer[overrides]
+	timer   *time.Timer
+	expires time.Time
+
+	// generation of overrides, increased on every change, so expired timer
+	// does not revert newer changes
+	generation uint64
+}
+
+type overrides struct {
+	verbose Level
+	option  Option
+	rules   map[string]Level
+}
+
+func (c *control) load() *overrides {
+	if c == nil {
+		return nil
+	}
+	return c.current.Load()
+}
+
+// change applies fn on copy of current overrides, when ttl is positive they
+// are reverted after that time
+func (c *control) change(fn func(*overrides), ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	o := overrides{}
+	if p := c.current.Load(); p != nil {
+		o = *p
+	}
+	o.rules = maps.Clone(o.rules)
+	fn(&o)
+	c.current.Store(&o)
+	c.stop()
+	if ttl > 0 {
+		g := c.generation
+		c.timer = time.AfterFunc(ttl, func() { c.expire(g) })
+		c.expires = time.Now().Add(ttl)
+	}
+}
+
+// reset reverts all overrides
+func (c *control) reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.current.Store(nil)
+	c.stop()
+}
+
+// expire reverts overrides when they are still of generation g
+func (c *control) expire(g uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.generation != g {
+		return
+	}
+	c.current.Store(nil)
+	c.stop()
+}
+
+// stop cancels pending revert and starts new generation, c.mu must be held
+func (c *control) stop() {
+	if c.timer != nil {
+		c.timer.Stop()
+	}
+	c.timer, c.expires = nil, time.Time{}
+	c.generation++
+}
+
+// Admin returns http.Handler which reports and changes at runtime verbosity
+// and Option of Logger l and all loggers derived from it.
+//
+//	GET    reports current settings
+//	PUT    changes settings with query parameters:
+//	       level   verbosity, ie dbg or debug
+//	       tag     makes level apply only to given tag, see Logger.Verbosity
+//	       options comma separated list of Option names, ie levels,tags,json
+//	       ttl     reverts all changes after given duration, ie 10m
+//	DELETE reverts all changes
+func (l *Logger) Admin() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead:
+		case http.MethodPut, http.MethodPost:
+			if err := l.admin(r); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		case http.MethodDelete:
+			l.ctl.reset()
+		default:
+			w.Header().Set("Allow", "GET, PUT, POST, DELETE")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(l.status())
+	})
+}
+
+func (l *Logger) admin(r *http.Request) error {
+	q := r.URL.Query()
+	var err error
+	var m Level
+	var o Option
+	var ttl time.Duration
+	if s := q.Get("level"); s != "" {
+		if m, err = ParseLevel(s); err != nil {
+			return err
+		}
+	}
+	if s, ok := q["options"]; ok {
+		if o, err = ParseOption(strings.Join(s, ",")); err != nil {
+			return err
+		}
+	}
+	if s := q.Get("ttl"); s != "" {
+		if ttl, err = time.ParseDuration(s); err != nil {
+			return err
+		}
+	}
+	t := q.Get("tag")
+	if t != "" && m == 0 {
+		return fmt.Errorf("sokool.log: tag %q given without level", t)
+	}
+	l.ctl.change(func(c *overrides) {
+		if _, ok := q["options"]; ok {
+			c.option = o
+		}
+		switch {
+		case m == 0:
+		case t == "":
+			c.verbose = m
+		default:
+			if c.rules == nil {
+				c.rules = map[string]Level{}
+			}
+			c.rules[t] = m
+		}
+	}, ttl)
+	return nil
+}
+
+func (l *Logger) status() Data {
+	rules := Data{}
+	for t, m := range l.rules {
+		rules[t] = m.String()
+	}
+	d := Data{
+		"level":   l.verbose.String(),
+		"tags":    rules,
+		"options": l.option.names(),
+	}
+	l.ctl.mu.Lock()
+	defer l.ctl.mu.Unlock()
+	if c := l.ctl.current.Load(); c != nil {
+		o := Data{}
+		if c.verbose != 0 {
+			o["level"] = c.verbose.String()
+		}
+		if c.option != 0 {
+			o["options"] = c.option.names()
+		}
+		if len(c.rules) > 0 {
+			rules := Data{}
+			for t, m := range c.rules {
+				rules[t] = m.String()
+			}
+			o["tags"] = rules
+		}
+		if !l.ctl.expires.IsZero() {
+			o["expires"] = l.ctl.expires
+		}
+		d["override"] = o
+	}
+	return d
+}
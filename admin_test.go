@@ -0,0 +1,96 @@
+package log_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sokool/log"
+)
+
+func TestLogger_Admin(t *testing.T) {
+	var b bytes.Buffer
+	l := log.New(&b, log.Levels|log.Tags).Verbosity(log.WARNING)
+	db := l.Tag("db")
+	h := l.Admin()
+	call := func(method, query string) (int, map[string]any) {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(method, "/?"+query, nil))
+		var d map[string]any
+		json.Unmarshal(w.Body.Bytes(), &d)
+		return w.Code, d
+	}
+	lines := func() string {
+		b.Reset()
+		l.Debugf("root")
+		db.Debugf("query")
+		l.Tag("http").Options(log.Levels).Infof("request")
+		return b.String()
+	}
+
+	if s := lines(); s != "" {
+		t.Fatal(s)
+	}
+	if c, _ := call(http.MethodPut, "level=dbg&tag=db"); c != http.StatusOK {
+		t.Fatal(c)
+	}
+	if s := lines(); s != "[DBG] [db] query\n" {
+		t.Fatal(s)
+	}
+	if c, _ := call(http.MethodPut, "level=info&options=levels,logfmt"); c != http.StatusOK {
+		t.Fatal(c)
+	}
+	if s := lines(); s != "level=debug msg=query\nlevel=info msg=request\n" {
+		t.Fatal(s)
+	}
+	c, d := call(http.MethodGet, "")
+	if o, _ := d["override"].(map[string]any); c != http.StatusOK || d["level"] != "WARNING" || o["level"] != "INFO" {
+		t.Fatal(c, d)
+	}
+	if c, _ := call(http.MethodDelete, ""); c != http.StatusOK || lines() != "" {
+		t.Fatal(c)
+	}
+	if c, _ := call(http.MethodPut, "level=loud"); c != http.StatusBadRequest {
+		t.Fatal(c)
+	}
+	if c, _ := call(http.MethodPut, "tag=db"); c != http.StatusBadRequest {
+		t.Fatal(c)
+	}
+
+	if c, _ := call(http.MethodPut, "level=dbg&ttl=20ms"); c != http.StatusOK || lines() == "" {
+		t.Fatal(c)
+	}
+	for i := 0; lines() != ""; i++ {
+		if i > 100 {
+			t.Fatal("override not reverted")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestLogger_AdminExpiredTTL(t *testing.T) {
+	var b bytes.Buffer
+	l := log.New(&b, log.Levels).Verbosity(log.WARNING)
+	h := l.Admin()
+	put := func(query string) {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/?"+query, nil))
+		if w.Code != http.StatusOK {
+			t.Fatal(w.Code)
+		}
+	}
+	// change without ttl made while earlier ttl expires must survive
+	for i := 0; i < 50; i++ {
+		put("level=info&ttl=1ms")
+		time.Sleep(time.Millisecond)
+		put("level=dbg")
+		time.Sleep(2 * time.Millisecond)
+		b.Reset()
+		if l.Debugf("kept"); b.String() != "[DBG] kept\n" {
+			t.Fatalf("iteration %d: override reverted by expired ttl", i)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+//go:build !race
+
+package log_test
+
+import (
+	"io"
+	"testing"
+
+	"github.com/sokool/log"
+)
+
+func TestAllocations(t *testing.T) {
+	type scenario struct {
+		description string
+		budget      float64
+		fn          func()
+	}
+	text := log.New(io.Discard, log.Date|log.Time|log.Levels|log.Tags|log.Properties)
+	colors := log.New(io.Discard, log.All&^log.Trace)
+	json := log.New(io.Discard, log.JSON|log.Levels|log.Tags|log.Time)
+	quiet := text.Verbosity(log.INFO)
+	cases := []scenario{
+		{"filtered Debugf without arguments", 0, func() { quiet.Debugf("cache miss") }},
+		{"text without arguments", 0, func() { text.Infof("request handled") }},
+		{"text with Levels, Tags and Colors", 1, func() { colors.Printf("http:wrn: request handled") }},
+		{"text with %s argument", 1, func() { text.Infof("request %s handled", "abc") }},
+		{"json", 2, func() { json.Printf("http:wrn: request handled") }},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			if n := testing.AllocsPerRun(1000, c.fn); n > c.budget {
+				t.Fatalf("expected at most %v allocations, got %v", c.budget, n)
+			}
+		})
+	}
+}
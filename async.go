@@ -0,0 +1,253 @@
+package log
+
+import (
+	"io"
+	"log"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Overflow decides what AsyncWriter does with message written when its buffer
+// is full
+type Overflow int
+
+const (
+	// DropNewest discards message being written
+	DropNewest Overflow = iota
+
+	// DropOldest discards the oldest buffered message to make room for new one
+	DropOldest
+
+	// Block makes Write wait until there is room in buffer
+	Block
+)
+
+// AsyncConfig describes AsyncWriter, zero values are replaced with defaults
+// described next to each field.
+type AsyncConfig struct {
+	// Size is the number of buffered messages, 1024 by default
+	Size int
+
+	// Overflow policy applied when buffer is full, DropNewest by default
+	Overflow Overflow
+
+	// Report is the frequency of reporting dropped messages, 10s by default
+	Report time.Duration
+
+	// Dropped receives number of messages dropped since last report, by
+	// default it is printed by standard log package
+	Dropped func(n uint64)
+}
+
+// AsyncWriter is io.Writer which stores messages in bounded ring buffer and
+// writes them into underlying io.Writer from background goroutine, so slow
+// writers do not block the caller.
+type AsyncWriter struct {
+	writer  io.Writer
+	conf    AsyncConfig
+	mu      sync.Mutex
+	cond    *sync.Cond
+	ring    [][]byte
+	head    int
+	size    int
+	busy    bool
+	closed  bool
+	report  bool
+	dropped atomic.Uint64
+	done    chan struct{}
+
+	// lock is held while writing into underlying writer, it is shared with
+	// loggers writing into it directly
+	lock sync.Locker
+}
+
+// NewAsync creates AsyncWriter which writes into w
+func NewAsync(w io.Writer, c AsyncConfig) *AsyncWriter {
+	return newAsync(w, c, nil)
+}
+
+// newAsync creates AsyncWriter which holds lock while writing into w
+func newAsync(w io.Writer, c AsyncConfig, lock sync.Locker) *AsyncWriter {
+	if c.Size <= 0 {
+		c.Size = 1024
+	}
+	if c.Report <= 0 {
+		c.Report = 10 * time.Second
+	}
+	if c.Dropped == nil {
+		c.Dropped = func(n uint64) { log.Printf("sokool.log: %d messages dropped", n) }
+	}
+	a := &AsyncWriter{
+		writer: w,
+		conf:   c,
+		ring:   make([][]byte, c.Size),
+		done:   make(chan struct{}),
+		lock:   lock,
+	}
+	a.cond = sync.NewCond(&a.mu)
+	go a.run()
+	go a.tick()
+	return a
+}
+
+// Write copies p into buffer, when it is full p is handled according to
+// Overflow policy. It returns ErrClosed after Close was called.
+func (a *AsyncWriter) Write(p []byte) (int, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for a.conf.Overflow == Block && a.size == len(a.ring) && !a.closed {
+		a.cond.Wait()
+	}
+	if a.closed {
+		return 0, ErrClosed
+	}
+	if a.size == len(a.ring) {
+		a.dropped.Add(1)
+		if a.conf.Overflow == DropNewest {
+			return len(p), nil
+		}
+		a.head, a.size = (a.head+1)%len(a.ring), a.size-1
+	}
+	i := (a.head + a.size) % len(a.ring)
+	a.ring[i] = append(a.ring[i][:0], p...)
+	a.size++
+	a.cond.Broadcast()
+	return len(p), nil
+}
+
+// Flush waits until all buffered messages are written into underlying writer
+func (a *AsyncWriter) Flush() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for (a.size > 0 || a.busy) && !a.closed {
+		a.cond.Wait()
+	}
+	return nil
+}
+
+// Close writes all buffered messages and stops AsyncWriter, underlying writer
+// is not closed.
+func (a *AsyncWriter) Close() error {
+	a.mu.Lock()
+	if a.closed {
+		a.mu.Unlock()
+		return ErrClosed
+	}
+	a.closed = true
+	a.cond.Broadcast()
+	a.mu.Unlock()
+	<-a.done
+	return nil
+}
+
+// Dropped returns total number of messages dropped so far
+func (a *AsyncWriter) Dropped() uint64 {
+	return a.dropped.Load()
+}
+
+func (a *AsyncWriter) run() {
+	defer close(a.done)
+
+	var b []byte
+	var n uint64
+	for {
+		a.mu.Lock()
+		a.busy = false
+		a.cond.Broadcast()
+		for a.size == 0 && !a.closed && !a.report {
+			a.cond.Wait()
+		}
+		if end := a.size == 0 && a.closed; a.report || end {
+			a.report = false
+			a.mu.Unlock()
+			if d := a.dropped.Load(); d > n {
+				a.conf.Dropped(d - n)
+				n = d
+			}
+			if end {
+				return
+			}
+			continue
+		}
+		// swap slot with spare buffer, so it can be written without lock
+		b, a.ring[a.head] = a.ring[a.head], b[:0]
+		a.head, a.size, a.busy = (a.head+1)%len(a.ring), a.size-1, true
+		a.cond.Broadcast()
+		a.mu.Unlock()
+		if err := a.write(b); err != nil {
+			log.Printf("sokool.log: message write failed %s", err)
+		}
+	}
+}
+
+func (a *AsyncWriter) write(p []byte) error {
+	if a.lock != nil {
+		a.lock.Lock()
+		defer a.lock.Unlock()
+	}
+	_, err := a.writer.Write(p)
+	return err
+}
+
+func (a *AsyncWriter) tick() {
+	t := time.NewTicker(a.conf.Report)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+			a.mu.Lock()
+			a.report = true
+			a.cond.Broadcast()
+			a.mu.Unlock()
+		case <-a.done:
+			return
+		}
+	}
+}
+
+// Async creates new Logger instance which writes messages through AsyncWriter,
+// number of dropped messages is reported with warning by Logger itself. Use
+// Logger.Flush and Logger.Close on graceful shutdown.
+//
+// Background goroutine writes under the same lock as Logger l and instances
+// derived from it, so they can share io.Writer with asynchronous one.
+func (l *Logger) Async(c AsyncConfig) *Logger {
+	if c.Dropped == nil {
+		r := l.new()
+		r.handlers, r.tag = nil, "log:async"
+		c.Dropped = func(d uint64) { r.Warnf("%d messages dropped", d) }
+	}
+	// AsyncWriter is safe for concurrent use, own lock keeps blocked Write
+	// and Flush from holding lock needed by background goroutine
+	n := l.new()
+	n.writer, n.mu = newAsync(l.writer, c, l.mu), &sync.Mutex{}
+	return n
+}
+
+// Flush writes all messages buffered by io.Writer, when it has Flush method
+func (l *Logger) Flush() error {
+	f, ok := l.writer.(interface{ Flush() error })
+	if !ok {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return f.Flush()
+}
+
+// Close flushes and closes io.Writer when it implements io.Closer, standard
+// output and error are never closed
+func (l *Logger) Close() error {
+	if err := l.Flush(); err != nil {
+		return err
+	}
+	c, ok := l.writer.(io.Closer)
+	if !ok || l.writer == os.Stdout || l.writer == os.Stderr {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return c.Close()
+}
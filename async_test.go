@@ -0,0 +1,162 @@
+package log_test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sokool/log"
+)
+
+// gate is io.Writer which blocks until it is opened
+type gate struct {
+	mu      sync.Mutex
+	lines   []string
+	entered chan struct{}
+	open    chan struct{}
+}
+
+func newGate() *gate {
+	return &gate{entered: make(chan struct{}, 1), open: make(chan struct{})}
+}
+
+func (g *gate) Write(p []byte) (int, error) {
+	select {
+	case g.entered <- struct{}{}:
+	default:
+	}
+	<-g.open
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.lines = append(g.lines, string(p))
+	return len(p), nil
+}
+
+func (g *gate) String() string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return strings.Join(g.lines, ",")
+}
+
+func TestAsyncWriter_Overflow(t *testing.T) {
+	type scenario struct {
+		description string
+		overflow    log.Overflow
+		output      string
+		dropped     uint64
+	}
+	cases := []scenario{
+		{"drop newest", log.DropNewest, "1,2,3", 2},
+		{"drop oldest", log.DropOldest, "1,4,5", 2},
+		{"block", log.Block, "1,2,3,4,5", 0},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			g := newGate()
+			a := log.NewAsync(g, log.AsyncConfig{Size: 2, Overflow: c.overflow, Dropped: func(uint64) {}})
+			a.Write([]byte("1"))
+			<-g.entered
+			done := make(chan struct{})
+			go func() {
+				for _, s := range []string{"2", "3", "4", "5"} {
+					a.Write([]byte(s))
+				}
+				close(done)
+			}()
+			if c.overflow == log.Block {
+				select {
+				case <-done:
+					t.Fatal("expected Write to block")
+				case <-time.After(20 * time.Millisecond):
+				}
+			}
+			close(g.open)
+			<-done
+			if err := a.Close(); err != nil {
+				t.Fatal(err)
+			}
+			if s := g.String(); s != c.output {
+				t.Fatalf("expected %s, got %s", c.output, s)
+			}
+			if n := a.Dropped(); n != c.dropped {
+				t.Fatalf("expected %d dropped, got %d", c.dropped, n)
+			}
+		})
+	}
+}
+
+func TestAsyncWriter_Close(t *testing.T) {
+	var b bytes.Buffer
+	a := log.NewAsync(&b, log.AsyncConfig{})
+	p := []byte("hello ")
+	a.Write(p)
+	copy(p, "HELLO ")
+	a.Write([]byte("world"))
+	if err := a.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if s := b.String(); s != "hello world" {
+		t.Fatalf("expected hello world, got %s", s)
+	}
+	if _, err := a.Write(p); !errors.Is(err, log.ErrClosed) {
+		t.Fatalf("expected ErrClosed, got %v", err)
+	}
+	if err := a.Close(); !errors.Is(err, log.ErrClosed) {
+		t.Fatalf("expected ErrClosed, got %v", err)
+	}
+}
+
+func TestLogger_Async(t *testing.T) {
+	g := newGate()
+	l := log.New(g, log.Levels|log.Tags).Async(log.AsyncConfig{Size: 1, Report: 10 * time.Millisecond})
+	l.Infof("first")
+	<-g.entered
+	l.Infof("second")
+	l.Infof("third")
+	close(g.open)
+	if err := l.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if s := g.String(); s != "[INF] first\n,[INF] second\n" {
+		t.Fatalf("unexpected output %q", s)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if s := g.String(); !strings.HasSuffix(s, "[WRN] [log:async] 1 messages dropped\n") {
+		t.Fatalf("expected dropped report, got %q", s)
+	}
+}
+
+func TestLogger_AsyncSiblings(t *testing.T) {
+	var b bytes.Buffer
+	l := log.New(&b, log.Levels)
+	a := l.Async(log.AsyncConfig{Overflow: log.Block, Size: 8})
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				l.Printf("sync")
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				a.Tag("async").Printf("async")
+			}
+		}()
+	}
+	wg.Wait()
+	if err := a.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if s, n := b.String(), strings.Count(b.String(), "\n"); n != 800 || strings.Count(s, "[INF] sync\n") != 400 {
+		t.Fatalf("expected 800 whole lines, got %d", n)
+	}
+}
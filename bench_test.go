@@ -0,0 +1,121 @@
+package log_test
+
+import (
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/sokool/log"
+)
+
+// Allocation budget per call, checked by TestAllocations:
+//
+//	filtered Debugf without arguments   0
+//	text without arguments              0
+//	text with Levels, Tags and Colors   1 (tags parsed from text prefix)
+//	text with %s argument               1 (argument boxed into interface)
+//	json                                2 (tags parsed from text prefix)
+//
+// Data attributes, struct arguments and errors allocate proportionally to
+// their size, as they are flattened or encoded with encoding/json.
+
+func BenchmarkLogger_FilteredDebugf(b *testing.B) {
+	l := log.New(io.Discard).Verbosity(log.INFO)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		l.Debugf("cache miss %s %v", "key", log.Data{"n": i})
+	}
+}
+
+func BenchmarkLogger_FilteredPrefix(b *testing.B) {
+	l := log.New(io.Discard).Verbosity(log.INFO)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		l.Printf("cache:dbg: miss %s %v", "key", log.Data{"n": i})
+	}
+}
+
+func BenchmarkLogger_WithoutTrace(b *testing.B) {
+	l := log.New(io.Discard, log.Levels|log.Tags|log.Properties)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		l.Printf("cache: miss %s", "key")
+	}
+}
+
+func BenchmarkLogger_WithTrace(b *testing.B) {
+	l := log.New(io.Discard, log.Levels|log.Tags|log.Properties|log.Trace)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		l.Printf("cache: miss %s", "key")
+	}
+}
+
+func BenchmarkLogger_Text(b *testing.B) {
+	l := log.New(io.Discard, log.Date|log.Time|log.Levels|log.Properties)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		l.Infof("request handled")
+	}
+}
+
+func BenchmarkLogger_Colored(b *testing.B) {
+	l := log.New(io.Discard, log.All&^log.Trace)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		l.Printf("http:wrn: request handled")
+	}
+}
+
+func BenchmarkLogger_JSON(b *testing.B) {
+	l := log.New(io.Discard, log.JSON|log.Levels|log.Tags|log.Time)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		l.Printf("http:wrn: request handled")
+	}
+}
+
+func BenchmarkLogger_Logfmt(b *testing.B) {
+	l := log.New(io.Discard, log.Logfmt|log.Levels|log.Tags|log.Time)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		l.Printf("http:wrn: request handled")
+	}
+}
+
+func BenchmarkLogger_Data(b *testing.B) {
+	d := log.Data{"user": log.Data{"id": 5, "name": "bob"}, "path": "/api/v1/users", "ms": 12.5}
+	l := log.New(io.Discard, log.Levels|log.Tags|log.Properties).With(log.Data{"service": "api"})
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		l.Printf("http: request %v", d)
+	}
+}
+
+func BenchmarkLogger_DataJSON(b *testing.B) {
+	d := log.Data{"user": log.Data{"id": 5, "name": "bob"}, "path": "/api/v1/users", "ms": 12.5}
+	l := log.New(io.Discard, log.JSON|log.Levels|log.Properties).With(log.Data{"service": "api"})
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		l.Printf("http: request %v", d)
+	}
+}
+
+func BenchmarkLogger_Error(b *testing.B) {
+	err := errors.New("connection refused")
+	l := log.New(io.Discard, log.Levels|log.Tags|log.Properties)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		l.Printf("db: query failed %v", err)
+	}
+}
+
+func BenchmarkLogger_Parallel(b *testing.B) {
+	l := log.New(io.Discard, log.Levels|log.Tags|log.Properties)
+	b.ReportAllocs()
+	b.RunParallel(func(p *testing.PB) {
+		for p.Next() {
+			l.Printf("worker: job %s done", "sync")
+		}
+	})
+}
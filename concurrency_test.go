@@ -0,0 +1,64 @@
+package log_test
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"regexp"
+	"sync"
+	"testing"
+
+	"github.com/sokool/log"
+)
+
+func TestLogger_Concurrency(t *testing.T) {
+	var b bytes.Buffer
+	var w sync.WaitGroup
+	const goroutines, lines = 32, 200
+
+	l := log.New(&b, log.Levels|log.Tags|log.Properties)
+	ll := []*log.Logger{
+		l,
+		l.Tag("db"),
+		l.Verbosity(log.DEBUG),
+		l.Options(log.Tags | log.Properties),
+		l.With(log.Data{"id": 1}).Tag("http"),
+	}
+	for i := 0; i < goroutines; i++ {
+		w.Add(1)
+		go func(l *log.Logger, i int) {
+			defer w.Done()
+			for j := 0; j < lines; j++ {
+				l.Printf("worker %d line %d %v", i, j, log.Data{"payload": "some longer text"})
+			}
+		}(ll[i%len(ll)], i)
+	}
+	w.Wait()
+
+	var n int
+	r := regexp.MustCompile(`^(\[INF\] )?(\[\w+\] )?worker \d+ line \d+ payload="some longer text"( id=1)?$`)
+	for s := bufio.NewScanner(&b); s.Scan(); n++ {
+		if !r.MatchString(s.Text()) {
+			t.Fatalf("malformed line %q", s.Text())
+		}
+	}
+	if n != goroutines*lines {
+		t.Fatalf("expected %d lines, got %d", goroutines*lines, n)
+	}
+}
+
+func TestLogger_ConcurrencyWriter(t *testing.T) {
+	var a, b bytes.Buffer
+	var w sync.WaitGroup
+
+	l := log.New(&a, log.Levels)
+	for i := 0; i < 16; i++ {
+		w.Add(2)
+		go func() { defer w.Done(); l.Writer(&b).Tag("b").Infof("b") }()
+		go func() { defer w.Done(); l.Tag("a").Errorf("a %v", fmt.Errorf("fail")) }()
+	}
+	w.Wait()
+	if a.Len() == 0 || b.Len() == 0 {
+		t.Fatal()
+	}
+}
@@ -0,0 +1,92 @@
+package log
+
+import "context"
+
+type contextKey int
+
+const (
+	loggerKey contextKey = iota
+	dataKey
+	spanKey
+)
+
+// NewContext returns copy of ctx which carries Logger l, use FromContext to
+// get it back
+func NewContext(ctx context.Context, l *Logger) context.Context {
+	return context.WithValue(ctx, loggerKey, l)
+}
+
+// FromContext returns Logger stored in ctx by NewContext, or Default when
+// there is none, with Data added by ContextWith
+func FromContext(ctx context.Context) *Logger {
+	l, ok := ctx.Value(loggerKey).(*Logger)
+	if !ok {
+		l = Default
+	}
+	return l.context(ctx)
+}
+
+// ContextWith returns copy of ctx with Data d, which is attached to every
+// Message logged with that context, ie request id
+func ContextWith(ctx context.Context, d Data) context.Context {
+	c, _ := ctx.Value(dataKey).(Data)
+	return context.WithValue(ctx, dataKey, c.merge(d))
+}
+
+// PrintfCtx works as Printf with Data from ctx attached to Message
+func (l *Logger) PrintfCtx(ctx context.Context, text string, args ...any) {
+	l.context(ctx).write(text, 0, args...)
+}
+
+// InfoCtx works as Infof with Data from ctx attached to Message
+func (l *Logger) InfoCtx(ctx context.Context, text string, args ...any) {
+	l.context(ctx).write(text, INFO, args...)
+}
+
+// WarnCtx works as Warnf with Data from ctx attached to Message
+func (l *Logger) WarnCtx(ctx context.Context, text string, args ...any) {
+	l.context(ctx).write(text, WARNING, args...)
+}
+
+// DebugCtx works as Debugf with Data from ctx attached to Message
+func (l *Logger) DebugCtx(ctx context.Context, text string, args ...any) {
+	l.context(ctx).write(text, DEBUG, args...)
+}
+
+// ErrorCtx works as Errorf with Data from ctx attached to Message
+func (l *Logger) ErrorCtx(ctx context.Context, text string, args ...any) {
+	l.context(ctx).write(text, ERROR, args...)
+}
+
+// context returns Logger with Data carried by ctx, trace_id and span_id are
+// added when ctx has SpanContext
+func (l *Logger) context(ctx context.Context) *Logger {
+	d, _ := ctx.Value(dataKey).(Data)
+	if c, ok := SpanFromContext(ctx); ok {
+		d = d.merge(Data{"trace_id": c.TraceID.String(), "span_id": c.SpanID.String()})
+	}
+	if len(d) > 0 {
+		return l.With(d)
+	}
+	return l
+}
+
+func PrintfCtx(ctx context.Context, format string, args ...any) {
+	FromContext(ctx).write(format, INFO, args...)
+}
+
+func InfoCtx(ctx context.Context, format string, args ...any) {
+	FromContext(ctx).write(format, INFO, args...)
+}
+
+func DebugCtx(ctx context.Context, format string, args ...any) {
+	FromContext(ctx).write(format, DEBUG, args...)
+}
+
+func ErrorCtx(ctx context.Context, format string, args ...any) {
+	FromContext(ctx).write(format, ERROR, args...)
+}
+
+func WarnCtx(ctx context.Context, format string, args ...any) {
+	FromContext(ctx).write(format, WARNING, args...)
+}
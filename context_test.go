@@ -0,0 +1,41 @@
+package log_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/sokool/log"
+)
+
+func TestContext(t *testing.T) {
+	var b bytes.Buffer
+	o := log.Levels | log.Tags | log.Properties | log.Trace
+	l := log.New(&b, o).Tag("api")
+
+	ctx := log.ContextWith(context.Background(), log.Data{"request_id": "r1"})
+	ctx = log.ContextWith(ctx, log.Data{"user": 7})
+	ctx = log.NewContext(ctx, l)
+
+	log.InfoCtx(ctx, "handled %v", log.Data{"ms": 3})
+	if s := b.String(); s != "[INF] [api] handled ms=3 request_id=r1 user=7 context_test.go:21\n" {
+		t.Fatal(s)
+	}
+
+	b.Reset()
+	l.Tag("db").Options(o&^log.Trace).ErrorCtx(ctx, "failed")
+	if s := b.String(); s != "[ERR] [db] failed request_id=r1 user=7\n" {
+		t.Fatal(s)
+	}
+
+	b.Reset()
+	slog.New(log.NewSlogHandler(l.Options(o&^log.Trace))).InfoContext(ctx, "slog", "n", 1)
+	if s := b.String(); s != "[INF] [api] slog n=1 request_id=r1 user=7\n" {
+		t.Fatal(s)
+	}
+
+	if log.FromContext(context.Background()) != log.Default {
+		t.Fatal("expected Default logger")
+	}
+}
@@ -0,0 +1,465 @@
+package log
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Encoder transforms Message into bytes written by Logger into io.Writer,
+// Option tells which parts of Message are expected in output
+type Encoder interface {
+	Encode(Message, Option) ([]byte, error)
+}
+
+// EncoderFunc allows to use ordinary function as Encoder
+type EncoderFunc func(Message, Option) ([]byte, error)
+
+func (f EncoderFunc) Encode(m Message, o Option) ([]byte, error) {
+	return f(m, o)
+}
+
+// Appender is implemented by Encoder which is able to append rendered Message
+// to given buffer, Logger uses it to avoid allocating bytes for each Message
+type Appender interface {
+	Append([]byte, Message, Option) ([]byte, error)
+}
+
+// TextEncoder renders Message as human readable line
+type TextEncoder struct{}
+
+func (e TextEncoder) Encode(m Message, o Option) ([]byte, error) {
+	return e.Append(nil, m, o)
+}
+
+func (TextEncoder) Append(b []byte, m Message, o Option) ([]byte, error) {
+	var n = len(b)
+	var c = o&Colors != 0
+	if o&Date != 0 {
+		b = m.CreatedAt.AppendFormat(b, "2006/01/02 ")
+	}
+	if o&Time != 0 {
+		b = m.CreatedAt.AppendFormat(b, "15:04:05.000000 ")
+	}
+	if o&Levels != 0 {
+		b = append(b, '[')
+		b = m.Level.append(b, true, c)
+		b = append(b, "] "...)
+	}
+	if o&Tags != 0 && len(m.Tags) > 0 {
+		b = append(b, '[')
+		b = m.appendTag(b, c)
+		b = append(b, "] "...)
+	}
+	b = m.appendText(b, c, o&Properties != 0)
+	if o&Properties != 0 && len(m.Data) > 0 {
+		b = append(b, ' ')
+		b = m.Data.appendProperties(b, c)
+	}
+	if o&Trace != 0 && m.File != "" {
+		b = append(b, ' ')
+		b = m.appendLocation(b, c)
+	}
+
+	return m.appendTrace(trim(b, n), c), nil
+}
+
+// JSONEncoder renders Message as json object with fields chosen by Option:
+// Date and Time enables date, Levels enables level, Tags enables tag and tags,
+// Trace enables file, func and line, Properties enables attr and Logger.With
+// data. Text, stack and error are always rendered. When none of these options
+// is given all fields are rendered. Logger.With data is dropped only when its
+// key is used by rendered field.
+type JSONEncoder struct {
+	// Keys renames fields, ie {"text": "msg", "date": "ts", "level": "severity"},
+	// field which already uses new key is dropped, renaming two fields to the
+	// same key is an error
+	Keys map[string]string
+
+	// Merge puts keys of %v attributes which are maps or structs into the top
+	// level object instead of attr array, other attributes stay in attr
+	Merge bool
+
+	// Nest puts merged attributes under given key, ie "fields"
+	Nest string
+
+	// Collision decides what happens when merged key is already taken
+	Collision Collision
+}
+
+// Collision tells JSONEncoder how to merge attribute which key is taken
+type Collision int
+
+const (
+	// CollisionKeep drops attribute and keeps existing field
+	CollisionKeep Collision = iota
+
+	// CollisionOverwrite replaces existing field with attribute
+	CollisionOverwrite
+
+	// CollisionRename stores attribute under key with attr_ prefix, repeated
+	// until key is free
+	CollisionRename
+)
+
+func (e JSONEncoder) Encode(m Message, o Option) ([]byte, error) {
+	return e.Append(nil, m, o)
+}
+
+func (e JSONEncoder) Append(b []byte, m Message, o Option) ([]byte, error) {
+	if o&(Date|Time|Levels|Tags|Trace|Properties) == 0 {
+		o |= Date | Time | Levels | Tags | Trace | Properties
+	}
+	var f Data
+	var a [16]field
+	ff := append(a[:0], field{key: "text", kind: textField})
+	if o&Tags != 0 {
+		ff = append(ff, field{key: "tag", kind: stringField, str: title(m.Tags)}, field{key: "tags", kind: tagsField})
+	}
+	if o&Levels != 0 {
+		ff = append(ff, field{key: "level", kind: stringField, str: m.Level.String()})
+	}
+	if o&Trace != 0 {
+		ff = append(ff,
+			field{key: "file", kind: stringField, str: m.File},
+			field{key: "func", kind: stringField, str: m.Func},
+			field{key: "line", kind: lineField})
+	}
+	if o&(Date|Time) != 0 {
+		ff = append(ff, field{key: "date", kind: dateField})
+	}
+	if o&Properties != 0 {
+		at := m.attrs()
+		if e.Merge {
+			var r []any
+			for _, v := range at {
+				if x, ok := data(v); ok {
+					f = f.merge(x)
+					continue
+				}
+				r = append(r, v)
+			}
+			at = r
+		}
+		if !e.Merge || len(at) > 0 {
+			ff = append(ff, field{key: "attr", kind: builtinField, value: at})
+		}
+	}
+	if len(m.Stack) > 0 {
+		ff = append(ff, field{key: "stack", kind: builtinField, value: m.Stack})
+	}
+	if err := m.error(); err != nil {
+		ff = append(ff, field{key: "error", kind: builtinField, value: errorData(err)})
+	}
+	if o&Properties != 0 {
+		for k, v := range m.Data {
+			if index(ff, k) != -1 {
+				continue
+			}
+			ff = append(ff, field{key: k, value: v})
+		}
+	}
+	for i := range ff {
+		if n, ok := e.Keys[ff[i].key]; ok && ff[i].kind != anyField && n != ff[i].key {
+			ff[i].key, ff[i].renamed = n, true
+		}
+	}
+	// renamed field displaces one which already uses its key
+	for i := range ff {
+		for j := range ff {
+			if !ff[i].renamed || i == j || ff[i].key != ff[j].key {
+				continue
+			}
+			if ff[j].renamed {
+				return b, fmt.Errorf("sokool.log: json fields renamed to the same key %q", ff[i].key)
+			}
+			ff[j].key = ""
+		}
+	}
+	if len(f) > 0 && e.Nest != "" {
+		f = Data{e.Nest: f}
+	}
+	var kk []string
+	if len(f) > 0 {
+		// sorted, so renamed attributes get the same keys every time
+		kk = slices.Sorted(maps.Keys(f))
+	}
+	for _, k := range kk {
+		v := f[k]
+		if i := index(ff, k); i != -1 {
+			switch e.Collision {
+			case CollisionKeep:
+				continue
+			case CollisionOverwrite:
+				ff[i].kind, ff[i].value = anyField, v
+				continue
+			case CollisionRename:
+				for index(ff, k) != -1 {
+					k = "attr_" + k
+				}
+			}
+		}
+		ff = append(ff, field{key: k, value: v})
+	}
+	slices.SortFunc(ff, func(a, b field) int { return strings.Compare(a.key, b.key) })
+
+	var err error
+	b = append(b, '{')
+	for _, x := range ff {
+		if x.key == "" {
+			continue
+		}
+		if b[len(b)-1] != '{' {
+			b = append(b, ',')
+		}
+		b = appendJSONString(b, x.key)
+		b = append(b, ':')
+		switch x.kind {
+		case textField:
+			b = appendText(b, m)
+		case stringField:
+			b = appendJSONString(b, x.str)
+		case tagsField:
+			b = appendJSONStrings(b, m.Tags)
+		case lineField:
+			b = strconv.AppendInt(b, int64(m.Line), 10)
+		case dateField:
+			b, err = appendJSONTime(b, m.CreatedAt)
+		default:
+			b, err = appendJSON(b, x.value)
+		}
+		if err != nil {
+			return b, err
+		}
+	}
+	return append(b, '}'), nil
+}
+
+// field of json object rendered by JSONEncoder, values of builtin fields are
+// taken from Message without boxing them into interface
+type field struct {
+	key     string
+	kind    int
+	str     string
+	value   any
+	renamed bool
+}
+
+const (
+	anyField = iota
+	builtinField
+	textField
+	stringField
+	tagsField
+	lineField
+	dateField
+)
+
+// index returns position of field with key k in ff or -1
+func index(ff []field, k string) int {
+	for i := range ff {
+		if ff[i].key == k {
+			return i
+		}
+	}
+	return -1
+}
+
+// appendText appends Message text without attributes as json string
+func appendText(b []byte, m Message) []byte {
+	p := buffers.Get().(*[]byte)
+	t := trim(m.appendText((*p)[:0], false, false), 0)
+	b = appendJSONString(b, string(t))
+	*p = t
+	buffers.Put(p)
+	return b
+}
+
+// appendJSON appends v encoded as json, common types are encoded without
+// reflection
+func appendJSON(b []byte, v any) ([]byte, error) {
+	switch x := v.(type) {
+	case string:
+		return appendJSONString(b, x), nil
+	case int:
+		return strconv.AppendInt(b, int64(x), 10), nil
+	case []string:
+		return appendJSONStrings(b, x), nil
+	case time.Time:
+		return appendJSONTime(b, x)
+	}
+	j, err := json.Marshal(v)
+	return append(b, j...), err
+}
+
+// appendJSONStrings appends ss as json array
+func appendJSONStrings(b []byte, ss []string) []byte {
+	if ss == nil {
+		return append(b, "null"...)
+	}
+	b = append(b, '[')
+	for i := range ss {
+		if i > 0 {
+			b = append(b, ',')
+		}
+		b = appendJSONString(b, ss[i])
+	}
+	return append(b, ']')
+}
+
+// appendJSONTime appends t as json string in RFC 3339 format
+func appendJSONTime(b []byte, t time.Time) ([]byte, error) {
+	if y := t.Year(); y < 0 || y >= 10000 {
+		j, err := t.MarshalJSON()
+		return append(b, j...), err
+	}
+	b = append(b, '"')
+	b = t.AppendFormat(b, time.RFC3339Nano)
+	return append(b, '"'), nil
+}
+
+// appendJSONString appends s as json string, escaped as encoding/json does
+func appendJSONString(b []byte, s string) []byte {
+	const hex = "0123456789abcdef"
+	b = append(b, '"')
+	var p int
+	for i := 0; i < len(s); {
+		if c := s[i]; c < utf8.RuneSelf {
+			if c >= 0x20 && c != '"' && c != '\\' && c != '<' && c != '>' && c != '&' {
+				i++
+				continue
+			}
+			b = append(b, s[p:i]...)
+			switch c {
+			case '"', '\\':
+				b = append(b, '\\', c)
+			case '\b':
+				b = append(b, '\\', 'b')
+			case '\f':
+				b = append(b, '\\', 'f')
+			case '\n':
+				b = append(b, '\\', 'n')
+			case '\r':
+				b = append(b, '\\', 'r')
+			case '\t':
+				b = append(b, '\\', 't')
+			default:
+				b = append(b, '\\', 'u', '0', '0', hex[c>>4], hex[c&0xF])
+			}
+			i++
+			p = i
+			continue
+		}
+		r, n := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && n == 1 {
+			b = append(b, s[p:i]...)
+			b = append(b, `\ufffd`...)
+			i += n
+			p = i
+			continue
+		}
+		if r == '\u2028' || r == '\u2029' {
+			b = append(b, s[p:i]...)
+			b = append(b, '\\', 'u', '2', '0', '2', hex[r&0xF])
+			i += n
+			p = i
+			continue
+		}
+		i += n
+	}
+	b = append(b, s[p:]...)
+	return append(b, '"')
+}
+
+// LogfmtEncoder renders Message as logfmt line, ie
+//
+//	time=2024-05-01T10:00:00Z level=error tags=db:pool msg="query failed" file=db.go:12 query.id=5
+//
+// Values with spaces, quotes, equal signs, control characters or empty ones
+// are quoted and escaped, flattened %v attributes follow the header fields.
+type LogfmtEncoder struct{}
+
+func (e LogfmtEncoder) Encode(m Message, o Option) ([]byte, error) {
+	return e.Append(nil, m, o)
+}
+
+func (LogfmtEncoder) Append(b []byte, m Message, o Option) ([]byte, error) {
+	var n = len(b)
+	if o&(Date|Time) != 0 {
+		b = m.CreatedAt.AppendFormat(append(b, "time="...), time.RFC3339Nano)
+		b = append(b, ' ')
+	}
+	if o&Levels != 0 {
+		b = logfmt(b, "level", m.Level.lower())
+	}
+	if t := m.Tag(false); o&Tags != 0 && t != "" {
+		b = logfmt(b, "tags", t)
+	}
+	t, d := m.split(nil)
+	b = logfmt(b, "msg", string(t))
+	if o&Trace != 0 && m.File != "" {
+		b = logfmt(b, "file", m.Location(false))
+	}
+	if len(m.Stack) > 0 {
+		b = logfmt(b, "stack", strings.TrimSpace(strings.ReplaceAll(m.Trace(false), "\n\t\t", " ")))
+	}
+	if err := m.error(); err != nil && o&Properties != 0 {
+		d = d.merge(Data{"error": errorData(err)})
+	}
+	if o&Properties != 0 {
+		for k, v := range d.merge(m.Data).Flat() {
+			b = logfmt(b, k, v)
+		}
+	}
+	return trim(b, n), nil
+}
+
+// split appends text of Message m with scalar arguments to b, map-like
+// arguments are left out and returned as Data
+func (m Message) split(b []byte) ([]byte, Data) {
+	var d Data
+	var n = len(b)
+	b = m.appendFormat(b, func(v any) any {
+		switch f := v.(type) {
+		case string:
+			return f
+		case []byte:
+			return string(f)
+		case error:
+			return f.Error()
+		}
+		if isNumber(v) {
+			return v
+		}
+		if x, ok := data(v); ok {
+			d = d.merge(x)
+		}
+		return ""
+	})
+	return trim(b, n), d
+}
+
+// logfmt appends key=value pair to b, key is sanitized and value quoted when
+// needed
+func logfmt(b []byte, key, value string) []byte {
+	b = append(b, strings.Map(func(r rune) rune {
+		if r <= ' ' || r == '=' || r == '"' || r == utf8.RuneError {
+			return '_'
+		}
+		return r
+	}, key)...)
+	b = append(b, '=')
+	if value == "" || strings.IndexFunc(value, func(r rune) bool {
+		return r <= ' ' || r == '=' || r == '"' || r == '\\' || r == 0x7f || r == utf8.RuneError
+	}) != -1 {
+		b = strconv.AppendQuote(b, value)
+	} else {
+		b = append(b, value...)
+	}
+	return append(b, ' ')
+}
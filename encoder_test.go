@@ -0,0 +1,202 @@
+package log_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/sokool/log"
+)
+
+func TestLogfmtEncoder(t *testing.T) {
+	type scenario struct {
+		description string
+		input       string
+		args        []any
+		options     log.Option
+		output      string
+	}
+	cases := []scenario{
+		{
+			description: "level tags and text",
+			input:       "db:pool:err: query failed",
+			options:     log.Levels | log.Tags,
+			output:      `level=error tags=db:pool msg="query failed"`,
+		},
+		{
+			description: "empty text",
+			input:       "dbg:",
+			options:     log.Levels,
+			output:      `level=debug msg=""`,
+		},
+		{
+			description: "scalar attributes stay in text, data is flattened",
+			input:       "user %v paid %v",
+			args:        []any{"bob", log.Data{"amount": 10, "card": log.Data{"type": "visa"}}},
+			options:     log.Properties,
+			output:      `msg="user bob paid" amount=10 card.type=visa`,
+		},
+		{
+			description: "quoting and escaping",
+			input:       "%v",
+			args:        []any{log.Data{"a": `say "hi"`, "b": "x=y", "c": "two\nlines", "d": "", "e": `back\slash`}},
+			options:     log.Properties,
+			output:      `msg="" a="say \"hi\"" b="x=y" c="two\nlines" d="" e="back\\slash"`,
+		},
+		{
+			description: "properties disabled",
+			input:       "hello %v",
+			args:        []any{log.Data{"a": 1}},
+			output:      `msg=hello`,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			m := log.NewMessage(c.input, 0, c.args...)
+			if s, _ := m.Render(c.options | log.Logfmt); string(s) != c.output {
+				t.Fatalf("expected `%s`, got `%s`", c.output, s)
+			}
+		})
+	}
+
+	m := log.NewMessage("wrn: trace", 0)
+	if s, _ := m.Render(log.Logfmt | log.Trace); string(s) != "msg=trace file=encoder_test.go:62" {
+		t.Fatal(string(s))
+	}
+}
+
+func TestJSONEncoder(t *testing.T) {
+	type scenario struct {
+		description string
+		options     log.Option
+		keys        map[string]string
+		output      string
+	}
+	cases := []scenario{
+		{
+			description: "levels and tags only",
+			options:     log.Levels | log.Tags,
+			output:      `{"level":"WARNING","tag":"Db","tags":["db"],"text":"slow"}`,
+		},
+		{
+			description: "properties with default data",
+			options:     log.Properties,
+			output:      `{"attr":[{"ms":30}],"service":"api","text":"slow"}`,
+		},
+		{
+			description: "renamed keys",
+			options:     log.Levels | log.Properties,
+			keys:        map[string]string{"text": "msg", "level": "severity", "date": "ts"},
+			output:      `{"attr":[{"ms":30}],"msg":"slow","service":"api","severity":"WARNING"}`,
+		},
+		{
+			description: "renamed onto built-in key",
+			options:     log.Levels,
+			keys:        map[string]string{"text": "level"},
+			output:      `{"level":"slow"}`,
+		},
+		{
+			description: "swapped keys",
+			options:     log.Levels,
+			keys:        map[string]string{"text": "level", "level": "text"},
+			output:      `{"level":"slow","text":"WARNING"}`,
+		},
+		{
+			description: "renamed onto data key",
+			options:     log.Properties,
+			keys:        map[string]string{"text": "service"},
+			output:      `{"attr":[{"ms":30}],"service":"slow"}`,
+		},
+	}
+	m := log.NewMessage("db:wrn: slow %v", 0, log.Data{"ms": 30})
+	m.Data = log.Data{"service": "api"}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			if s, _ := (log.JSONEncoder{Keys: c.keys}).Encode(m, c.options); string(s) != c.output {
+				t.Fatalf("expected `%s`, got `%s`", c.output, s)
+			}
+		})
+	}
+	if _, err := (log.JSONEncoder{Keys: map[string]string{"text": "msg", "level": "msg"}}).Encode(m, log.Levels); err == nil {
+		t.Fatal("expected error for fields renamed to the same key")
+	}
+	var b bytes.Buffer
+	log.New(&b, log.JSON|log.Levels|log.Properties).With(log.Data{"file": "upload.csv", "line": 3, "level": "ignored"}).Infof("imported")
+	if x := `{"attr":null,"file":"upload.csv","level":"INFO","line":3,"text":"imported"}` + "\n"; b.String() != x {
+		t.Fatalf("expected `%s`, got `%s`", x, b.String())
+	}
+	if s, _ := m.Render(log.JSON); !json.Valid(s) || !bytes.Contains(s, []byte(`"file":`)) || !bytes.Contains(s, []byte(`"date":`)) {
+		t.Fatalf("expected all fields, got `%s`", s)
+	}
+}
+
+func TestJSONEncoder_Merge(t *testing.T) {
+	type user struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	}
+	type scenario struct {
+		description string
+		encoder     log.JSONEncoder
+		output      string
+	}
+	cases := []scenario{
+		{
+			description: "attributes nested under key",
+			encoder:     log.JSONEncoder{Merge: true, Nest: "fields"},
+			output:      `{"attr":["card"],"fields":{"id":5,"level":"high","name":"bob"},"level":"INFO","text":"user paid with"}`,
+		},
+		{
+			description: "attributes become top level fields, collision keeps field",
+			encoder:     log.JSONEncoder{Merge: true},
+			output:      `{"attr":["card"],"id":5,"level":"INFO","name":"bob","text":"user paid with"}`,
+		},
+		{
+			description: "collision overwrites field",
+			encoder:     log.JSONEncoder{Merge: true, Collision: log.CollisionOverwrite},
+			output:      `{"attr":["card"],"id":5,"level":"high","name":"bob","text":"user paid with"}`,
+		},
+		{
+			description: "collision renames attribute",
+			encoder:     log.JSONEncoder{Merge: true, Collision: log.CollisionRename},
+			output:      `{"attr":["card"],"attr_level":"high","id":5,"level":"INFO","name":"bob","text":"user paid with"}`,
+		},
+	}
+	m := log.NewMessage("user %v paid with %v %v", 0, user{5, "bob"}, "card", log.Data{"level": "high"})
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			if s, _ := c.encoder.Encode(m, log.Levels|log.Properties); string(s) != c.output {
+				t.Fatalf("expected `%s`, got `%s`", c.output, s)
+			}
+		})
+	}
+	m = log.NewMessage("paid %v", 0, log.Data{"level": 1, "attr_level": 2})
+	for i := 0; i < 20; i++ {
+		s, _ := log.JSONEncoder{Merge: true, Collision: log.CollisionRename}.Encode(m, log.Levels|log.Properties)
+		if x := `{"attr_attr_level":1,"attr_level":2,"level":"INFO","text":"paid"}`; string(s) != x {
+			t.Fatalf("expected `%s`, got `%s`", x, s)
+		}
+	}
+}
+
+func TestJSONEncoder_Escaping(t *testing.T) {
+	for _, s := range []string{
+		`quote " and \ backslash`,
+		"tab\tnewline\ncarriage\r",
+		"<html> & </html>",
+		"line \u2028 paragraph \u2029",
+		"control \x01 \x1f",
+		"invalid \xff utf8",
+		"zażółć gęślą jaźń",
+	} {
+		m := log.NewMessage("%v", 0, s)
+		b, err := log.JSONEncoder{}.Encode(m, log.Properties)
+		if err != nil {
+			t.Fatal(err)
+		}
+		e, _ := json.Marshal(m.Properties()["attr"])
+		if !bytes.Contains(b, e) {
+			t.Fatalf("expected %s in %s", e, b)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package log
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+)
+
+// Env creates new Logger instance configured with LOG and LOG_FORMAT
+// environment variables, see Logger.Spec and Logger.Format. Invalid values are
+// reported by standard log package and ignored.
+func (l *Logger) Env() *Logger {
+	n := l
+	if s, ok := os.LookupEnv("LOG"); ok {
+		if x, err := n.Spec(s); err != nil {
+			log.Printf("%s in LOG variable", err)
+		} else {
+			n = x
+		}
+	}
+	if s, ok := os.LookupEnv("LOG_FORMAT"); ok {
+		if x, err := n.Format(s); err != nil {
+			log.Printf("%s in LOG_FORMAT variable", err)
+		} else {
+			n = x
+		}
+	}
+	return n
+}
+
+// Spec creates new Logger instance with verbosity described by comma separated
+// list, where entry without tag sets Logger verbosity and tag=level entries
+// set verbosity of tags, ie
+//
+//	wrn,db=dbg,http:client=inf
+func (l *Logger) Spec(s string) (*Logger, error) {
+	n := l
+	for _, e := range strings.Split(s, ",") {
+		if e = strings.TrimSpace(e); e == "" {
+			continue
+		}
+		t, v, ok := strings.Cut(e, "=")
+		if !ok {
+			t, v = "", t
+		}
+		m, err := ParseLevel(v)
+		if err != nil {
+			return nil, err
+		}
+		if t = strings.TrimSpace(t); t == "" {
+			n = n.Verbosity(m)
+			continue
+		}
+		n = n.Verbosity(m, t)
+	}
+	return n, nil
+}
+
+// Format creates new Logger instance which renders messages as json, logfmt
+// or text
+func (l *Logger) Format(s string) (*Logger, error) {
+	o := l.option &^ (JSON | Logfmt)
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "json":
+		o |= JSON
+	case "logfmt":
+		o |= Logfmt
+	case "text", "":
+	default:
+		return nil, fmt.Errorf("sokool.log: unknown format %q", s)
+	}
+	return l.Options(o), nil
+}
@@ -0,0 +1,34 @@
+package log_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/sokool/log"
+)
+
+func TestLogger_Env(t *testing.T) {
+	var b bytes.Buffer
+	t.Setenv("LOG", "wrn, db=dbg ,http:client=INFO")
+	t.Setenv("LOG_FORMAT", "logfmt")
+
+	l := log.New(&b, log.Levels|log.Tags).Env()
+	l.Printf("inf: hidden")
+	l.Printf("db:pool:dbg: shown")
+	l.Printf("http:client:inf: shown")
+	if s := b.String(); s != "level=debug tags=db:pool msg=shown\nlevel=info tags=http:client msg=shown\n" {
+		t.Fatal(s)
+	}
+
+	if _, err := l.Spec("db=loud"); err == nil {
+		t.Fatal("expected error")
+	}
+	if _, err := l.Format("xml"); err == nil {
+		t.Fatal("expected error")
+	}
+	b.Reset()
+	t.Setenv("LOG_FORMAT", "xml")
+	if l.Env().Printf("wrn: still logfmt"); b.String() != "level=warning msg=\"still logfmt\"\n" {
+		t.Fatal(b.String())
+	}
+}
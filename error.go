@@ -0,0 +1,54 @@
+package log
+
+import (
+	"errors"
+	"fmt"
+)
+
+// DataError is implemented by errors which carry domain fields, ie code, they
+// are rendered next to error message and type
+type DataError interface {
+	error
+	LogData() Data
+}
+
+// errorData describes err with its message, type, chain of wrapped errors,
+// branches of errors.Join and fields of every DataError in chain, outer
+// errors fields take precedence
+func errorData(err error) Data {
+	var f Data
+	var c []Data
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		if x, ok := e.(DataError); ok {
+			f = x.LogData().merge(f)
+		}
+		if e != err {
+			c = append(c, Data{"message": e.Error(), "type": fmt.Sprintf("%T", e)})
+		}
+		if j, ok := e.(interface{ Unwrap() []error }); ok {
+			var b []Data
+			for _, x := range j.Unwrap() {
+				if x != nil {
+					b = append(b, errorData(x))
+				}
+			}
+			f = f.merge(Data{"join": b})
+			break
+		}
+	}
+	d := f.merge(Data{"message": err.Error(), "type": fmt.Sprintf("%T", err)})
+	if len(c) > 0 {
+		d["chain"] = c
+	}
+	return d
+}
+
+// error returns first error argument of Message
+func (m Message) error() error {
+	for _, a := range m.ARGS {
+		if err, ok := a.(error); ok {
+			return err
+		}
+	}
+	return nil
+}
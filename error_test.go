@@ -0,0 +1,62 @@
+package log_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/sokool/log"
+)
+
+type codeError struct {
+	code int
+}
+
+func (e codeError) Error() string {
+	return fmt.Sprintf("code %d", e.code)
+}
+
+func (e codeError) LogData() log.Data {
+	return log.Data{"code": e.code}
+}
+
+func TestMessage_Error(t *testing.T) {
+	err := fmt.Errorf("charge failed: %w", codeError{402})
+	m := log.NewMessage("payments: %v", 0, err)
+	if s, _ := m.Render(log.Levels | log.Tags | log.Properties); string(s) != "[ERR] [payments] charge failed: code 402" {
+		t.Fatal(string(s))
+	}
+	if s, _ := m.Render(log.Logfmt | log.Properties); string(s) != `msg="charge failed: code 402" error.chain.0.message="code 402" error.chain.0.type=log_test.codeError error.code=402 error.message="charge failed: code 402" error.type=*fmt.wrapError` {
+		t.Fatal(string(s))
+	}
+
+	var d struct {
+		Attr  []any
+		Error map[string]any
+	}
+	b, _ := m.Render(log.JSON)
+	if err := json.Unmarshal(b, &d); err != nil {
+		t.Fatal(err)
+	}
+	e := map[string]any{
+		"message": "charge failed: code 402",
+		"type":    "*fmt.wrapError",
+		"code":    float64(402),
+		"chain":   []any{map[string]any{"message": "code 402", "type": "log_test.codeError"}},
+	}
+	if !reflect.DeepEqual(d.Error, e) || d.Attr[0] != "charge failed: code 402" {
+		t.Fatal(string(b))
+	}
+
+	m = log.NewMessage("batch %s", 0, errors.Join(codeError{1}, errors.New("disk full")))
+	b, _ = m.Render(log.JSON)
+	if err := json.Unmarshal(b, &d); err != nil {
+		t.Fatal(err)
+	}
+	j, _ := d.Error["join"].([]any)
+	if len(j) != 2 || j[0].(map[string]any)["code"] != float64(1) || j[1].(map[string]any)["message"] != "disk full" {
+		t.Fatal(string(b))
+	}
+}
@@ -0,0 +1,309 @@
+package log
+
+import (
+	"compress/gzip"
+	"errors"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// FileConfig describes when File is rotated and how long rotated files are
+// kept, zero values disable given policy.
+type FileConfig struct {
+	// MaxSize in bytes after which file is rotated
+	MaxSize int64
+
+	// Every rotates file when time crosses multiple of it, ie 24*time.Hour
+	// rotates at UTC midnight
+	Every time.Duration
+
+	// Compress rotated files with gzip
+	Compress bool
+
+	// MaxFiles is the number of rotated files kept
+	MaxFiles int
+
+	// MaxAge of rotated files kept, ie 7*24*time.Hour
+	MaxAge time.Duration
+
+	// Perm of created files, 0644 by default
+	Perm os.FileMode
+}
+
+// File is io.Writer which writes into file and rotates it according to
+// FileConfig. Rotated files are named after it with time of rotation, ie
+// app.log becomes app-2006-01-02T15-04-05.000.log. File is reopened when
+// process receives SIGHUP, so it cooperates with external logrotate, and it is
+// safe for concurrent use.
+type File struct {
+	path    string
+	conf    FileConfig
+	mu      sync.Mutex
+	file    *os.File
+	size    int64
+	created time.Time
+	hup     chan os.Signal
+	done    chan struct{}
+	jobs    sync.WaitGroup
+	clean   sync.Mutex
+	closed  bool
+}
+
+// rotation is layout of time in rotated file names
+const rotation = "2006-01-02T15-04-05.000"
+
+// NewFile opens or creates file at path, its directory is created when
+// needed
+func NewFile(path string, c FileConfig) (*File, error) {
+	if c.Perm == 0 {
+		c.Perm = 0644
+	}
+	f := &File{
+		path: path,
+		conf: c,
+		hup:  make(chan os.Signal, 1),
+		done: make(chan struct{}),
+	}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	signal.Notify(f.hup, syscall.SIGHUP)
+	go f.watch()
+	return f, nil
+}
+
+// Write appends p to file, rotating it first when p does not fit in MaxSize
+// or Every period has passed. File which failed to open during rotation is
+// opened again.
+func (f *File) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return 0, ErrClosed
+	}
+	if f.file == nil {
+		if err := f.open(); err != nil {
+			return 0, err
+		}
+	}
+	if f.expired(int64(len(p))) {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// Rotate moves current file aside and opens new one
+func (f *File) Rotate() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return ErrClosed
+	}
+	return f.rotate()
+}
+
+// Reopen closes and opens file again, it is used when file was moved by
+// external tool
+func (f *File) Reopen() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return ErrClosed
+	}
+	if err := f.release(); err != nil {
+		return err
+	}
+	return f.open()
+}
+
+// Close closes file and waits until rotated files are compressed and removed
+func (f *File) Close() error {
+	f.mu.Lock()
+	if f.closed {
+		f.mu.Unlock()
+		return ErrClosed
+	}
+	signal.Stop(f.hup)
+	close(f.done)
+	f.closed = true
+	err := f.release()
+	f.mu.Unlock()
+	f.jobs.Wait()
+	return err
+}
+
+// expired tells if file must be rotated before n bytes are written
+func (f *File) expired(n int64) bool {
+	if f.conf.MaxSize > 0 && f.size > 0 && f.size+n > f.conf.MaxSize {
+		return true
+	}
+	e := f.conf.Every
+	return e > 0 && !time.Now().Truncate(e).Equal(f.created.Truncate(e))
+}
+
+func (f *File) open() error {
+	if err := os.MkdirAll(filepath.Dir(f.path), 0755); err != nil {
+		return err
+	}
+	o, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, f.conf.Perm)
+	if err != nil {
+		return err
+	}
+	i, err := o.Stat()
+	if err != nil {
+		o.Close()
+		return err
+	}
+	f.file, f.size, f.created = o, i.Size(), time.Now()
+	if f.size > 0 {
+		f.created = i.ModTime()
+	}
+	return nil
+}
+
+// release closes current file, when next one can not be opened Write tries
+// again
+func (f *File) release() error {
+	if f.file == nil {
+		return nil
+	}
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
+
+func (f *File) rotate() error {
+	if err := f.release(); err != nil {
+		return err
+	}
+	n := f.rotated(time.Now())
+	if err := os.Rename(f.path, n); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return errors.Join(err, f.open())
+	}
+	if err := f.open(); err != nil {
+		return err
+	}
+	f.jobs.Add(1)
+	go func() {
+		defer f.jobs.Done()
+		f.clean.Lock()
+		defer f.clean.Unlock()
+		if err := f.cleanup(n); err != nil {
+			log.Printf("sokool.log: file rotation %s", err)
+		}
+	}()
+	return nil
+}
+
+// rotated returns unused name for file rotated at t
+func (f *File) rotated(t time.Time) string {
+	d, b := filepath.Split(f.path)
+	x := filepath.Ext(b)
+	for ; ; t = t.Add(time.Millisecond) {
+		n := filepath.Join(d, strings.TrimSuffix(b, x)+"-"+t.Format(rotation)+x)
+		if !exists(n) && !exists(n+".gz") {
+			return n
+		}
+	}
+}
+
+// cleanup compresses file n and removes rotated files exceeding MaxFiles or
+// MaxAge
+func (f *File) cleanup(n string) error {
+	var err error
+	if f.conf.Compress {
+		err = compress(n)
+	}
+	if f.conf.MaxFiles <= 0 && f.conf.MaxAge <= 0 {
+		return err
+	}
+	d, b := filepath.Split(f.path)
+	x := filepath.Ext(b)
+	p := strings.TrimSuffix(b, x) + "-"
+	ee, e := os.ReadDir(filepath.Clean(d))
+	if e != nil {
+		return errors.Join(err, e)
+	}
+	var rr []string
+	for _, r := range ee {
+		s, ok := strings.CutSuffix(strings.TrimSuffix(r.Name(), ".gz"), x)
+		if s, k := strings.CutPrefix(s, p); ok && k {
+			if _, e := time.Parse(rotation, s); e == nil {
+				rr = append(rr, r.Name())
+			}
+		}
+	}
+	slices.Sort(rr)
+	slices.Reverse(rr)
+	for i, r := range rr {
+		n := filepath.Join(d, r)
+		s, e := os.Stat(n)
+		if e != nil {
+			continue
+		}
+		if (f.conf.MaxFiles > 0 && i >= f.conf.MaxFiles) ||
+			(f.conf.MaxAge > 0 && time.Since(s.ModTime()) > f.conf.MaxAge) {
+			err = errors.Join(err, os.Remove(n))
+		}
+	}
+	return err
+}
+
+// watch reopens file on SIGHUP
+func (f *File) watch() {
+	for {
+		select {
+		case <-f.hup:
+			if err := f.Reopen(); err != nil && !errors.Is(err, ErrClosed) {
+				log.Printf("sokool.log: file reopen %s", err)
+			}
+		case <-f.done:
+			return
+		}
+	}
+}
+
+// compress replaces file n with its gzip version n.gz
+func compress(n string) error {
+	r, err := os.Open(n)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	i, err := r.Stat()
+	if err != nil {
+		return err
+	}
+	w, err := os.OpenFile(n+".gz", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, i.Mode())
+	if err != nil {
+		return err
+	}
+	z := gzip.NewWriter(w)
+	if _, err = io.Copy(z, r); err == nil {
+		err = z.Close()
+	}
+	if err = errors.Join(err, w.Close()); err != nil {
+		os.Remove(n + ".gz")
+		return err
+	}
+	os.Chtimes(n+".gz", i.ModTime(), i.ModTime())
+	r.Close()
+	return os.Remove(n)
+}
+
+func exists(n string) bool {
+	_, err := os.Lstat(n)
+	return err == nil
+}
@@ -0,0 +1,214 @@
+package log_test
+
+import (
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/sokool/log"
+)
+
+func TestFile_Rotation(t *testing.T) {
+	type scenario struct {
+		description string
+		config      log.FileConfig
+		wait        time.Duration
+		rotated     int
+	}
+	cases := []scenario{
+		{"by size", log.FileConfig{MaxSize: 10}, 0, 2},
+		{"by size with retention", log.FileConfig{MaxSize: 10, MaxFiles: 1}, 0, 1},
+		{"by time", log.FileConfig{Every: 20 * time.Millisecond}, 30 * time.Millisecond, 2},
+		{"without policy", log.FileConfig{}, 0, 0},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			d := t.TempDir()
+			f, err := log.NewFile(filepath.Join(d, "app.log"), c.config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, s := range []string{"first\n", "second\n", "third\n"} {
+				f.Write([]byte(s))
+				time.Sleep(c.wait)
+			}
+			if err = f.Close(); err != nil {
+				t.Fatal(err)
+			}
+			if nn := rotated(t, d); len(nn) != c.rotated {
+				t.Fatalf("expected %d rotated files, got %v", c.rotated, nn)
+			}
+			if c.rotated == 0 {
+				return
+			}
+			if b, _ := os.ReadFile(filepath.Join(d, "app.log")); string(b) != "third\n" {
+				t.Fatalf("expected third line in current file, got %q", b)
+			}
+		})
+	}
+}
+
+func TestFile_Compress(t *testing.T) {
+	d := t.TempDir()
+	old := filepath.Join(d, "app-2020-01-01T00-00-00.000.log.gz")
+	if err := os.WriteFile(old, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	os.Chtimes(old, time.Now().Add(-48*time.Hour), time.Now().Add(-48*time.Hour))
+
+	f, err := log.NewFile(filepath.Join(d, "app.log"), log.FileConfig{Compress: true, MaxAge: 24 * time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := log.New(f, log.Levels)
+	l.Infof("before rotation")
+	if err = f.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	l.Infof("after rotation")
+	if err = l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	nn := rotated(t, d)
+	if len(nn) != 1 || !strings.HasSuffix(nn[0], ".log.gz") || nn[0] == filepath.Base(old) {
+		t.Fatalf("expected one compressed file, got %v", nn)
+	}
+	r, err := os.Open(filepath.Join(d, nn[0]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	z, err := gzip.NewReader(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := io.ReadAll(z); string(b) != "[INF] before rotation\n" {
+		t.Fatalf("unexpected rotated content %q", b)
+	}
+}
+
+func TestFile_Reopen(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("SIGHUP is not supported")
+	}
+	d := t.TempDir()
+	n := filepath.Join(d, "app.log")
+	f, err := log.NewFile(n, log.FileConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	f.Write([]byte("first\n"))
+	if err = os.Rename(n, n+".1"); err != nil {
+		t.Fatal(err)
+	}
+	p, _ := os.FindProcess(os.Getpid())
+	if err = p.Signal(syscall.SIGHUP); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 100; i++ {
+		if _, err = os.Stat(n); err == nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	f.Write([]byte("second\n"))
+	if b, _ := os.ReadFile(n); string(b) != "second\n" {
+		t.Fatalf("expected reopened file with second line, got %q", b)
+	}
+}
+
+func TestFile_FailedRotation(t *testing.T) {
+	d := filepath.Join(t.TempDir(), "logs")
+	n := filepath.Join(d, "app.log")
+	f, err := log.NewFile(n, log.FileConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// directory replaced by regular file, rotated file can not be moved aside
+	// nor opened again
+	if err = os.RemoveAll(d); err != nil {
+		t.Fatal(err)
+	}
+	if err = os.WriteFile(d, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err = f.Rotate(); err == nil {
+		t.Fatal("expected rotation error")
+	}
+	if _, err = f.Write([]byte("lost\n")); err == nil || errors.Is(err, os.ErrClosed) {
+		t.Fatalf("expected open error, got %v", err)
+	}
+	os.Remove(d)
+	if _, err = f.Write([]byte("kept\n")); err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := os.ReadFile(n); string(b) != "kept\n" {
+		t.Fatalf("expected file opened again, got %q", b)
+	}
+	if err = f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = f.Write([]byte("closed\n")); !errors.Is(err, log.ErrClosed) {
+		t.Fatalf("expected ErrClosed, got %v", err)
+	}
+	if err = f.Close(); !errors.Is(err, log.ErrClosed) {
+		t.Fatalf("expected ErrClosed, got %v", err)
+	}
+}
+
+func TestFile_Concurrency(t *testing.T) {
+	d := t.TempDir()
+	f, err := log.NewFile(filepath.Join(d, "app.log"), log.FileConfig{MaxSize: 256})
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := log.New(f, log.Levels)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				l.Tag("worker").Infof("message %d", j)
+			}
+		}()
+	}
+	wg.Wait()
+	if err = f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	var n int
+	for _, r := range append(rotated(t, d), "app.log") {
+		b, _ := os.ReadFile(filepath.Join(d, r))
+		n += strings.Count(string(b), "\n")
+	}
+	if n != 400 {
+		t.Fatalf("expected 400 lines, got %d", n)
+	}
+}
+
+// rotated returns names of rotated files in directory d
+func rotated(t *testing.T, d string) []string {
+	ee, err := os.ReadDir(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var nn []string
+	for _, e := range ee {
+		if e.Name() != "app.log" {
+			nn = append(nn, e.Name())
+		}
+	}
+	slices.Sort(nn)
+	return nn
+}
@@ -2,49 +2,345 @@ package log
 
 import (
 	"bytes"
+	"compress/gzip"
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
 	"net/http"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
 type Handler func(Message)
 
+var (
+	// ErrDropped is reported when Message is dropped because queue is full
+	ErrDropped = errors.New("sokool.log: message dropped")
+
+	// ErrClosed is reported when Message is handled by closed sink
+	ErrClosed = errors.New("sokool.log: sink closed")
+)
+
+// HTTPConfig describes how HTTPSink delivers batches of Message. Zero values
+// are replaced with defaults described next to each field.
+type HTTPConfig struct {
+	// Frequency of sending pending messages, 5s by default
+	Frequency time.Duration
+
+	// MaxBatch is the maximum number of messages in single request, batch is
+	// sent as soon as it is reached, 100 by default
+	MaxBatch int
+
+	// MaxBytes is the maximum size of request body before compression, larger
+	// batches are split, 0 means no limit
+	MaxBytes int
+
+	// Retries is the number of attempts made after failed request
+	Retries int
+
+	// Backoff is the delay before first retry, doubled on every next attempt,
+	// 500ms by default
+	Backoff time.Duration
+
+	// Queue is the number of messages waiting for delivery, 1024 by default
+	Queue int
+
+	// Block makes Handle wait when queue is full, by default Message is
+	// dropped and number of dropped messages is reported as ErrDropped every
+	// Frequency
+	Block bool
+
+	// Gzip compresses request body
+	Gzip bool
+
+	// Header is added to each request, ie Authorization
+	Header http.Header
+
+	// Client sends requests, http.DefaultClient by default
+	Client *http.Client
+
+	// Encode builds request body from batch, json array by default
+	Encode func([]Message) ([]byte, error)
+
+	// Errors receives every delivery error, by default they are printed by
+	// standard log package
+	Errors func(error)
+}
+
+// HTTPSink delivers messages in batches to an HTTP endpoint, use its Handle
+// method as Logger Handler.
+type HTTPSink struct {
+	url   string
+	conf  HTTPConfig
+	queue chan Message
+	flush chan request
+	close chan request
+	done  chan struct{}
+	once  sync.Once
+	drops atomic.Uint64
+
+	// mu guards closed, run takes it before final drain, so no Message is
+	// queued after it
+	mu     sync.RWMutex
+	closed bool
+}
+
+// NewHTTP creates HTTPSink which posts batches of messages to url
+func NewHTTP(url string, c HTTPConfig) *HTTPSink {
+	if c.Frequency <= 0 {
+		c.Frequency = 5 * time.Second
+	}
+	if c.MaxBatch <= 0 {
+		c.MaxBatch = 100
+	}
+	if c.Backoff <= 0 {
+		c.Backoff = 500 * time.Millisecond
+	}
+	if c.Queue <= 0 {
+		c.Queue = 1024
+	}
+	if c.Client == nil {
+		c.Client = http.DefaultClient
+	}
+	if c.Encode == nil {
+		c.Encode = func(m []Message) ([]byte, error) { return json.Marshal(m) }
+	}
+	if c.Errors == nil {
+		c.Errors = func(err error) { log.Printf("sokool.log: http sink %s", err) }
+	}
+	s := &HTTPSink{
+		url:   url,
+		conf:  c,
+		queue: make(chan Message, c.Queue),
+		flush: make(chan request),
+		close: make(chan request, 1),
+		done:  make(chan struct{}),
+	}
+	go s.run()
+	return s
+}
+
+// HTTP creates Handler which sends messages to url every frequency.
+//
+// Deprecated: sink created by HTTP can not be stopped and loses queued
+// messages on exit, use NewHTTP and its Close method instead.
 func HTTP(url string, frequency time.Duration) Handler {
-	write := func(url string, m []Message) error {
-		body, err := json.Marshal(m)
-		if err != nil {
-			return err
+	return NewHTTP(url, HTTPConfig{Frequency: frequency}).Handle
+}
+
+// Handle queues Message m for delivery
+func (s *HTTPSink) Handle(m Message) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed {
+		s.conf.Errors(ErrClosed)
+		return
+	}
+	if s.conf.Block {
+		select {
+		case s.queue <- m:
+		case <-s.done:
+			s.conf.Errors(ErrClosed)
 		}
+		return
+	}
+	select {
+	case s.queue <- m:
+	default:
+		s.drops.Add(1)
+	}
+}
 
-		res, err := http.Post(url, "application/json", bytes.NewReader(body))
-		if err != nil {
-			return err
+// Dropped returns total number of messages dropped so far
+func (s *HTTPSink) Dropped() uint64 {
+	return s.drops.Load()
+}
+
+// Flush sends all queued messages and waits until they are delivered
+func (s *HTTPSink) Flush(ctx context.Context) error {
+	r := request{ctx: ctx, err: make(chan error, 1)}
+	select {
+	case s.flush <- r:
+	case <-s.done:
+		return ErrClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	select {
+	case err := <-r.err:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close sends all queued messages and stops HTTPSink, messages handled after
+// Close are reported as ErrClosed
+func (s *HTTPSink) Close(ctx context.Context) error {
+	r := request{ctx: ctx, err: make(chan error, 1)}
+	c := false
+	s.once.Do(func() { s.close <- r; c = true })
+	if !c {
+		return ErrClosed
+	}
+	select {
+	case err := <-r.err:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *HTTPSink) run() {
+	t := time.NewTicker(s.conf.Frequency)
+	defer t.Stop()
+
+	var mm []Message
+	var n uint64
+	// dropped reports messages dropped since last report
+	dropped := func() {
+		if d := s.drops.Load(); d > n {
+			s.conf.Errors(fmt.Errorf("%w: %d messages", ErrDropped, d-n))
+			n = d
+		}
+	}
+	for {
+		select {
+		case m := <-s.queue:
+			if mm = append(mm, m); len(mm) >= s.conf.MaxBatch {
+				s.report(s.send(context.Background(), mm))
+				mm = nil
+			}
+		case <-t.C:
+			dropped()
+			s.report(s.send(context.Background(), mm))
+			mm = nil
+		case r := <-s.flush:
+			dropped()
+			r.err <- s.send(r.ctx, s.drain(mm))
+			mm = nil
+		case r := <-s.close:
+			close(s.done)
+			s.mu.Lock()
+			s.closed = true
+			s.mu.Unlock()
+			dropped()
+			r.err <- s.send(r.ctx, s.drain(mm))
+			return
+		}
+	}
+}
+
+// drain appends all messages waiting in queue to mm
+func (s *HTTPSink) drain(mm []Message) []Message {
+	for {
+		select {
+		case m := <-s.queue:
+			mm = append(mm, m)
+		default:
+			return mm
 		}
+	}
+}
+
+// send delivers mm in batches limited by MaxBatch and MaxBytes
+func (s *HTTPSink) send(ctx context.Context, mm []Message) error {
+	var err error
+	for len(mm) > 0 {
+		n := min(len(mm), s.conf.MaxBatch)
+		err = errors.Join(err, s.batch(ctx, mm[:n]))
+		mm = mm[n:]
+	}
+	return err
+}
 
-		if res.StatusCode < http.StatusOK || res.StatusCode >= http.StatusBadRequest {
+func (s *HTTPSink) batch(ctx context.Context, mm []Message) error {
+	b, err := s.conf.Encode(mm)
+	if err != nil {
+		return err
+	}
+	if s.conf.MaxBytes > 0 && len(b) > s.conf.MaxBytes {
+		if len(mm) == 1 {
+			return fmt.Errorf("sokool.log: message of %d bytes exceeds MaxBytes", len(b))
+		}
+		return errors.Join(s.batch(ctx, mm[:len(mm)/2]), s.batch(ctx, mm[len(mm)/2:]))
+	}
+	if s.conf.Gzip {
+		var z bytes.Buffer
+		w := gzip.NewWriter(&z)
+		if _, err = w.Write(b); err != nil {
+			return err
+		}
+		if err = w.Close(); err != nil {
 			return err
 		}
+		b = z.Bytes()
+	}
+	for i := 0; ; i++ {
+		if err = s.post(ctx, b); err == nil || i >= s.conf.Retries || !retryable(err) {
+			return err
+		}
+		select {
+		case <-time.After(s.conf.Backoff << i):
+		case <-ctx.Done():
+			return errors.Join(err, ctx.Err())
+		}
+	}
+}
 
-		return res.Body.Close()
+func (s *HTTPSink) post(ctx context.Context, body []byte) error {
+	r, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
 	}
+	r.Header.Set("Content-Type", "application/json")
+	for k, v := range s.conf.Header {
+		r.Header[k] = v
+	}
+	if s.conf.Gzip {
+		r.Header.Set("Content-Encoding", "gzip")
+	}
+	res, err := s.conf.Client.Do(r)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	io.Copy(io.Discard, res.Body)
+	if res.StatusCode < http.StatusOK || res.StatusCode >= http.StatusBadRequest {
+		return &statusError{code: res.StatusCode}
+	}
+	return nil
+}
 
-	ch := make(chan Message, 128)
-	go func() {
-		var mm []Message
-		for {
-			select {
-			case <-time.After(frequency):
-				if len(mm) == 0 {
-					continue
-				}
+func (s *HTTPSink) report(err error) {
+	if err != nil {
+		s.conf.Errors(err)
+	}
+}
 
-				write(url, mm)
-				mm = []Message{}
-			case m := <-ch:
-				mm = append(mm, m)
-			}
-		}
-	}()
+type request struct {
+	ctx context.Context
+	err chan error
+}
 
-	return func(m Message) { ch <- m }
+type statusError struct {
+	code int
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("sokool.log: unexpected status %d %s", e.code, http.StatusText(e.code))
+}
+
+// retryable tells if request that failed with err should be repeated, client
+// errors other than timeout and too many requests are permanent
+func retryable(err error) bool {
+	var s *statusError
+	if !errors.As(err, &s) {
+		return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+	}
+	return s.code >= 500 || s.code == http.StatusRequestTimeout || s.code == http.StatusTooManyRequests
 }
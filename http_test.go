@@ -0,0 +1,200 @@
+package log_test
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sokool/log"
+)
+
+type collector struct {
+	sync.Mutex
+	batches [][]map[string]any
+	fails   int
+	status  int
+	header  http.Header
+}
+
+func (c *collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	c.Lock()
+	defer c.Unlock()
+	if c.fails > 0 {
+		c.fails--
+		w.WriteHeader(c.status)
+		return
+	}
+	var b io.Reader = r.Body
+	if r.Header.Get("Content-Encoding") == "gzip" {
+		z, err := gzip.NewReader(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		b = z
+	}
+	var mm []map[string]any
+	if err := json.NewDecoder(b).Decode(&mm); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	c.batches, c.header = append(c.batches, mm), r.Header
+}
+
+func (c *collector) count() (batches, messages int) {
+	c.Lock()
+	defer c.Unlock()
+	for _, b := range c.batches {
+		messages += len(b)
+	}
+	return len(c.batches), messages
+}
+
+func TestHTTPSink_Batches(t *testing.T) {
+	c := &collector{}
+	srv := httptest.NewServer(c)
+	defer srv.Close()
+
+	s := log.NewHTTP(srv.URL, log.HTTPConfig{Frequency: time.Hour, MaxBatch: 3, Block: true})
+	for i := 0; i < 7; i++ {
+		s.Handle(log.NewMessage("test: message %d", 0, i))
+	}
+	if err := s.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if b, m := c.count(); b != 3 || m != 7 {
+		t.Fatalf("expected 3 batches with 7 messages, got %d with %d", b, m)
+	}
+	if c.batches[0][0]["text"] != "message 0" || c.batches[2][0]["text"] != "message 6" {
+		t.Fatal(c.batches)
+	}
+	if err := s.Close(context.Background()); !errors.Is(err, log.ErrClosed) {
+		t.Fatal(err)
+	}
+}
+
+func TestHTTPSink_MaxBytes(t *testing.T) {
+	c := &collector{}
+	srv := httptest.NewServer(c)
+	defer srv.Close()
+
+	m := log.NewMessage("some longer text which makes message big enough", 0)
+	b, _ := json.Marshal([]log.Message{m, m})
+	s := log.NewHTTP(srv.URL, log.HTTPConfig{Frequency: time.Hour, MaxBytes: len(b)})
+	for i := 0; i < 5; i++ {
+		s.Handle(m)
+	}
+	if err := s.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if b, n := c.count(); b != 3 || n != 5 {
+		t.Fatalf("expected 3 batches with 5 messages, got %d with %d", b, n)
+	}
+}
+
+func TestHTTPSink_Retries(t *testing.T) {
+	c := &collector{fails: 2, status: http.StatusServiceUnavailable}
+	srv := httptest.NewServer(c)
+	defer srv.Close()
+
+	s := log.NewHTTP(srv.URL, log.HTTPConfig{
+		Frequency: time.Hour,
+		Retries:   2,
+		Backoff:   time.Millisecond,
+		Gzip:      true,
+		Header:    http.Header{"Authorization": {"Bearer secret"}},
+	})
+	s.Handle(log.NewMessage("retried", 0))
+	if err := s.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if _, n := c.count(); n != 1 || c.header.Get("Authorization") != "Bearer secret" {
+		t.Fatal(n, c.header)
+	}
+
+	c.fails, c.status = 1, http.StatusBadRequest
+	s.Handle(log.NewMessage("rejected", 0))
+	if err := s.Flush(context.Background()); err == nil {
+		t.Fatal("expected permanent failure")
+	}
+}
+
+func TestHTTPSink_Drop(t *testing.T) {
+	var mu sync.Mutex
+	var ee []error
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { <-release }))
+	defer srv.Close()
+
+	s := log.NewHTTP(srv.URL, log.HTTPConfig{
+		Frequency: time.Hour,
+		MaxBatch:  1,
+		Queue:     2,
+		Errors: func(err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			ee = append(ee, err)
+		},
+	})
+	h := log.New(io.Discard).Handlers(s.Handle)
+	for i := 0; i < 10; i++ {
+		h.Printf("flood")
+	}
+	close(release)
+	if err := s.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if s.Dropped() < 7 {
+		t.Fatalf("expected at least 7 dropped messages, got %d", s.Dropped())
+	}
+	// dropped messages are reported together
+	if len(ee) != 1 || !errors.Is(ee[0], log.ErrDropped) || ee[0].Error() != fmt.Sprintf("%s: %d messages", log.ErrDropped, s.Dropped()) {
+		t.Fatalf("expected single report of dropped messages, got %v", ee)
+	}
+}
+
+func TestHTTPSink_CloseConcurrently(t *testing.T) {
+	c := &collector{}
+	srv := httptest.NewServer(c)
+	defer srv.Close()
+
+	var closed atomic.Int64
+	s := log.NewHTTP(srv.URL, log.HTTPConfig{
+		Frequency: time.Hour,
+		Queue:     1000,
+		Errors: func(err error) {
+			if errors.Is(err, log.ErrClosed) {
+				closed.Add(1)
+			}
+		},
+	})
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 200; j++ {
+				s.Handle(log.NewMessage("message", 0))
+			}
+		}()
+	}
+	time.Sleep(time.Millisecond)
+	if err := s.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	wg.Wait()
+	if _, m := c.count(); m+int(closed.Load()) != 800 {
+		t.Fatalf("expected 800 messages delivered or reported, got %d and %d", m, closed.Load())
+	}
+}
@@ -0,0 +1,194 @@
+package log
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// JournalConfig describes messages sent by JournalSink, zero values are
+// replaced with defaults described next to each field.
+type JournalConfig struct {
+	// Identifier is SYSLOG_IDENTIFIER of messages without tags, process name
+	// by default
+	Identifier string
+
+	// Timeout of connecting to journald and sending single message, 5 seconds
+	// by default
+	Timeout time.Duration
+
+	// Redial is the period after failed connection attempt in which messages
+	// are dropped instead of connecting again, 1 second by default
+	Redial time.Duration
+
+	// Report is the frequency of reporting entries dropped because they are
+	// too large, 10s by default
+	Report time.Duration
+
+	// Errors receives every delivery error, by default they are printed by
+	// standard log package
+	Errors func(error)
+}
+
+// JournalSink sends messages to systemd journald with its native protocol,
+// use its Handle method as Logger Handler. Data attributes are flattened and
+// sent as upper case journal fields, ie {"user": {"id": 5}} becomes USER_ID=5.
+//
+// Every message is sent in single datagram, messages larger than socket send
+// buffer, usually about 200kB, are dropped and their number is reported every
+// Report period.
+type JournalSink struct {
+	conf    JournalConfig
+	socket  *socket
+	dropped atomic.Uint64
+
+	// mu guards report of dropped entries
+	mu       sync.Mutex
+	reported uint64
+	next     time.Time
+	err      error
+}
+
+// NewJournal creates JournalSink connected to journald unix socket at address,
+// /run/systemd/journal/socket when empty
+func NewJournal(address string, c JournalConfig) (*JournalSink, error) {
+	if address == "" {
+		address = "/run/systemd/journal/socket"
+	}
+	if c.Identifier == "" {
+		c.Identifier = filepath.Base(os.Args[0])
+	}
+	if c.Timeout == 0 {
+		c.Timeout = 5 * time.Second
+	}
+	if c.Redial == 0 {
+		c.Redial = time.Second
+	}
+	if c.Report == 0 {
+		c.Report = 10 * time.Second
+	}
+	if c.Errors == nil {
+		c.Errors = func(err error) { log.Printf("sokool.log: journal sink %s", err) }
+	}
+	k, err := dial("unixgram", address, c.Timeout, c.Redial)
+	if err != nil {
+		return nil, err
+	}
+	return &JournalSink{conf: c, socket: k}, nil
+}
+
+// Handle sends Message m as journal entry. When journald is restarted
+// connection is established again, messages handled meanwhile are reported as
+// ErrDropped.
+func (s *JournalSink) Handle(m Message) {
+	p := buffers.Get().(*[]byte)
+	defer buffers.Put(p)
+	*p = s.append((*p)[:0], m)
+	if cap(*p) > 64<<10 {
+		defer func() { *p = make([]byte, 0, 1024) }()
+	}
+	err := s.socket.write(*p)
+	switch {
+	case err == nil:
+	case errors.Is(err, syscall.EMSGSIZE):
+		s.dropped.Add(1)
+		s.report(err, false)
+	default:
+		s.conf.Errors(err)
+	}
+}
+
+// Dropped returns total number of entries dropped because they are too large
+func (s *JournalSink) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+// Close reports pending dropped entries and closes journald socket, messages
+// handled after Close are reported as ErrClosed
+func (s *JournalSink) Close() error {
+	s.report(nil, true)
+	return s.socket.close()
+}
+
+// report reports entries dropped since last report, at most once per Report
+// period unless forced
+func (s *JournalSink) report(err error, force bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err != nil {
+		s.err = err
+	}
+	d := s.dropped.Load()
+	if d == s.reported || !force && time.Now().Before(s.next) {
+		return
+	}
+	s.conf.Errors(fmt.Errorf("sokool.log: %d journal entries too large, dropped: %w", d-s.reported, s.err))
+	s.reported, s.next = d, time.Now().Add(s.conf.Report)
+}
+
+// append appends Message m as journal fields to b
+func (s *JournalSink) append(b []byte, m Message) []byte {
+	id := s.conf.Identifier
+	if len(m.Tags) > 0 {
+		id = m.Tags[0]
+	}
+	t, d := m.split(nil)
+	if err := m.error(); err != nil {
+		d = d.merge(Data{"error": errorData(err)})
+	}
+	d = d.merge(m.Data)
+
+	b = journal(b, "MESSAGE", string(t))
+	b = journal(b, "PRIORITY", strconv.Itoa(severity(m.Level)))
+	b = journal(b, "SYSLOG_IDENTIFIER", id)
+	if m.File != "" {
+		b = journal(b, "CODE_FILE", m.File)
+		b = journal(b, "CODE_LINE", strconv.Itoa(m.Line))
+		b = journal(b, "CODE_FUNC", m.Func)
+	}
+	for k, v := range d.Flat("_") {
+		switch k = journalKey(k); k {
+		case "", "MESSAGE", "PRIORITY", "SYSLOG_IDENTIFIER", "CODE_FILE", "CODE_LINE", "CODE_FUNC":
+			continue
+		}
+		b = journal(b, k, v)
+	}
+	return b
+}
+
+// journal appends field of native journal protocol to b, values with new line
+// are written with their length
+func journal(b []byte, key, value string) []byte {
+	b = append(b, key...)
+	if !strings.Contains(value, "\n") {
+		return append(append(append(b, '='), value...), '\n')
+	}
+	b = binary.LittleEndian.AppendUint64(append(b, '\n'), uint64(len(value)))
+	return append(append(b, value...), '\n')
+}
+
+// journalKey converts key into journal field name, which consist of upper case
+// letters, digits and underscores and does not start with underscore or
+// digit
+func journalKey(key string) string {
+	k := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		}
+		return '_'
+	}, key)
+	k = strings.TrimLeft(k, "_0123456789")
+	return k[:min(len(k), 64)]
+}
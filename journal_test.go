@@ -0,0 +1,148 @@
+package log_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"net"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/sokool/log"
+)
+
+func TestJournalSink(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("unix sockets are not supported")
+	}
+	a := filepath.Join(t.TempDir(), "journal.sock")
+	l, err := net.ListenPacket("unixgram", a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	s, err := log.NewJournal(a, log.JournalConfig{Identifier: "app"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type scenario struct {
+		description string
+		input       string
+		args        []any
+		fields      map[string]string
+	}
+	cases := []scenario{
+		{
+			description: "message with data",
+			input:       "db:err: query %s failed %v",
+			args:        []any{"users", log.Data{"table": "users", "query": map[string]any{"rows": 5}, "_id": 7, "message": "ignored"}},
+			fields: map[string]string{
+				"MESSAGE":           "query users failed",
+				"PRIORITY":          "3",
+				"SYSLOG_IDENTIFIER": "db",
+				"TABLE":             "users",
+				"QUERY_ROWS":        "5",
+				"ID":                "7",
+			},
+		},
+		{
+			description: "multiline message without tags",
+			input:       "dbg: first\nsecond",
+			fields: map[string]string{
+				"MESSAGE":           "first\nsecond",
+				"PRIORITY":          "7",
+				"SYSLOG_IDENTIFIER": "app",
+			},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			m := log.NewMessage(c.input, 0, c.args...)
+			s.Handle(m)
+			ff := receive(t, l)
+			c.fields["CODE_FILE"], c.fields["CODE_FUNC"] = m.File, m.Func
+			c.fields["CODE_LINE"] = strconv.Itoa(m.Line)
+			if len(ff) != len(c.fields) {
+				t.Fatalf("expected %v, got %v", c.fields, ff)
+			}
+			for k, v := range c.fields {
+				if ff[k] != v {
+					t.Fatalf("expected %s=%q, got %q", k, v, ff[k])
+				}
+			}
+		})
+	}
+	if err = s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err = s.Close(); !errors.Is(err, log.ErrClosed) {
+		t.Fatalf("expected ErrClosed, got %v", err)
+	}
+}
+
+func TestJournalSink_Large(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("unix sockets are not supported")
+	}
+	a := filepath.Join(t.TempDir(), "journal.sock")
+	l, err := net.ListenPacket("unixgram", a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	var ee []error
+	s, err := log.NewJournal(a, log.JournalConfig{Errors: func(err error) { ee = append(ee, err) }})
+	if err != nil {
+		t.Fatal(err)
+	}
+	large := strings.Repeat("x", 4<<20)
+	for i := 0; i < 3; i++ {
+		s.Handle(log.NewMessage(large, 0))
+	}
+	if len(ee) != 1 || !errors.Is(ee[0], syscall.EMSGSIZE) {
+		t.Fatalf("expected single EMSGSIZE error, got %v", ee)
+	}
+	s.Handle(log.NewMessage("small", 0))
+	if ff := receive(t, l); ff["MESSAGE"] != "small" {
+		t.Fatalf("expected small message, got %v", ff)
+	}
+	// entries dropped after first report are reported on Close
+	if err = s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if s.Dropped() != 3 || len(ee) != 2 || !strings.Contains(ee[1].Error(), " 2 journal entries") || !errors.Is(ee[1], syscall.EMSGSIZE) {
+		t.Fatalf("expected 2 more dropped entries reported on Close, got %d %v", s.Dropped(), ee)
+	}
+}
+
+// receive reads datagram of journal native protocol from l
+func receive(t *testing.T, l net.PacketConn) map[string]string {
+	b := make([]byte, 4096)
+	l.SetReadDeadline(time.Now().Add(time.Second))
+	n, _, err := l.ReadFrom(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ff := map[string]string{}
+	for b = b[:n]; len(b) > 0; {
+		i := bytes.IndexAny(b, "=\n")
+		if i < 0 {
+			t.Fatalf("malformed datagram %q", b)
+		}
+		k := string(b[:i])
+		if b[i] == '=' {
+			j := bytes.IndexByte(b, '\n')
+			ff[k], b = string(b[i+1:j]), b[j+1:]
+			continue
+		}
+		s := int(binary.LittleEndian.Uint64(b[i+1:]))
+		ff[k], b = string(b[i+9:i+9+s]), b[i+10+s:]
+	}
+	return ff
+}
@@ -1,6 +1,9 @@
 package log
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 const (
 	DEBUG   Level = 4
@@ -12,25 +15,33 @@ const (
 type Level int
 
 func (l Level) Render(short, color bool) string {
-	s := "%s"
+	return string(l.append(nil, short, color))
+}
+
+func (l Level) append(b []byte, short, color bool) []byte {
 	if color {
 		switch l {
 		case ERROR:
-			s = "\x1b[31;1m%s\x1b[0m"
+			b = append(b, "\x1b[31;1m"...)
 		case WARNING:
-			s = "\x1b[33;1m%s\x1b[0m"
+			b = append(b, "\x1b[33;1m"...)
 		case INFO:
-			s = "\x1b[32;1m%s\x1b[0m"
+			b = append(b, "\x1b[32;1m"...)
 		case DEBUG:
-			s = "\x1b[36;1m%s\x1b[0m"
+			b = append(b, "\x1b[36;1m"...)
 		default:
-			s = "\x1b[39;1m%s\x1b[0m"
+			b = append(b, "\x1b[39;1m"...)
 		}
 	}
 	if short {
-		return fmt.Sprintf(s, l.GoString())
+		b = append(b, l.GoString()...)
+	} else {
+		b = append(b, l.String()...)
 	}
-	return fmt.Sprintf(s, l.String())
+	if color {
+		b = append(b, "\x1b[0m"...)
+	}
+	return b
 }
 
 func (l Level) String() string {
@@ -48,6 +59,22 @@ func (l Level) String() string {
 	}
 }
 
+// lower returns lower case name of Level
+func (l Level) lower() string {
+	switch l {
+	case DEBUG:
+		return "debug"
+	case INFO:
+		return "info"
+	case WARNING:
+		return "warning"
+	case ERROR:
+		return "error"
+	default:
+		return "unknown"
+	}
+}
+
 func (l Level) GoString() string {
 	switch l {
 	case DEBUG:
@@ -65,3 +92,50 @@ func (l Level) GoString() string {
 }
 
 var levels = map[string]Level{"dbg": DEBUG, "err": ERROR, "inf": INFO, "wrn": WARNING}
+
+// ParseLevel converts short (dbg, inf, wrn, err) or long (debug, info,
+// warning, error) name into Level, case is ignored
+func ParseLevel(s string) (Level, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if l, ok := levels[s]; ok {
+		return l, nil
+	}
+	for _, l := range []Level{DEBUG, INFO, WARNING, ERROR} {
+		if strings.ToLower(l.String()) == s {
+			return l, nil
+		}
+	}
+	if s == "warn" {
+		return WARNING, nil
+	}
+	return 0, fmt.Errorf("sokool.log: unknown level %q", s)
+}
+
+// verbosity returns Level threshold for message with given tags, the most
+// specific pattern from rules matching tags wins, def is returned when none
+// matches
+func verbosity(rules map[string]Level, tags []string, def Level) Level {
+	var n, w, b = -1, 0, ""
+	for p, l := range rules {
+		pp := strings.Split(p, ":")
+		if len(pp) > len(tags) || len(pp) < n {
+			continue
+		}
+		var c int
+		for i := range pp {
+			if pp[i] == "*" {
+				c++
+				continue
+			}
+			if pp[i] != tags[i] {
+				c = -1
+				break
+			}
+		}
+		if c == -1 || (len(pp) == n && (c > w || (c == w && p > b))) {
+			continue
+		}
+		n, w, b, def = len(pp), c, p, l
+	}
+	return def
+}
@@ -1,12 +1,18 @@
 package log
 
 import (
+	"fmt"
 	"io"
 	"log"
+	"maps"
 	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
 )
 
-var Default = New(os.Stdout, All)
+var Default = New(os.Stdout, All).Env()
 
 // Option ...
 type Option int64
@@ -36,9 +42,53 @@ const (
 	// JSON makes output with json format instead text
 	JSON
 
+	// Logfmt makes output with logfmt key=value format instead text
+	Logfmt
+
 	All = Date | Time | Levels | Tags | Trace | Properties | Colors
 )
 
+var options = map[string]Option{
+	"date":       Date,
+	"time":       Time,
+	"levels":     Levels,
+	"tags":       Tags,
+	"trace":      Trace,
+	"properties": Properties,
+	"colors":     Colors,
+	"json":       JSON,
+	"logfmt":     Logfmt,
+	"all":        All,
+}
+
+// ParseOption converts comma separated list of Option names, ie
+// "levels,tags,json", into Option
+func ParseOption(s string) (Option, error) {
+	var o Option
+	for _, n := range strings.Split(s, ",") {
+		if n = strings.ToLower(strings.TrimSpace(n)); n == "" {
+			continue
+		}
+		v, ok := options[n]
+		if !ok {
+			return 0, fmt.Errorf("sokool.log: unknown option %q", n)
+		}
+		o |= v
+	}
+	return o, nil
+}
+
+func (o Option) names() []string {
+	var s []string
+	for n, v := range options {
+		if v != All && o&v != 0 {
+			s = append(s, n)
+		}
+	}
+	slices.Sort(s)
+	return s
+}
+
 // Logger support three types(levels) of logging
 //
 // INF - default, when you want emphasize that something important (not negative)
@@ -51,16 +101,23 @@ const (
 //
 // ERR - when your code is a place where error is received but there is no
 // good way of handling that situation you might log it
-// todo
-//   - default attributes data added to each Message
-//   - log.Format option
+//
+// Logger and all instances derived from it are safe for concurrent use, each
+// Message is written into io.Writer with single Write call.
 type Logger struct {
 	writer   io.Writer
 	verbose  Level
+	rules    map[string]Level
 	tag      string
 	option   Option
 	trace    int
+	stack    int
+	filter   func(Frame) bool
 	handlers []Handler
+	data     Data
+	encoder  Encoder
+	mu       *sync.Mutex
+	ctl      *control
 }
 
 // New instance of logger
@@ -79,6 +136,8 @@ func New(w io.Writer, o ...Option) *Logger {
 		verbose: DEBUG,
 		trace:   2,
 		option:  o[0],
+		mu:      &sync.Mutex{},
+		ctl:     &control{},
 	}
 }
 
@@ -89,6 +148,14 @@ func (l *Logger) Tag(name string) *Logger {
 	return n
 }
 
+// With creates new instance of Logger and Data d is attached to each Message
+// it produces. Keys of d are merged with those already given to Logger.
+func (l *Logger) With(d Data) *Logger {
+	n := l.new()
+	n.data = n.data.merge(d)
+	return n
+}
+
 // Handlers creates new instance of Logger and all Message's are passed into
 // Handler just after it is writer to io.Writer
 func (l *Logger) Handlers(h ...Handler) *Logger {
@@ -97,19 +164,44 @@ func (l *Logger) Handlers(h ...Handler) *Logger {
 	return n
 }
 
-// Verbosity determines what Level of logging should be delivered to io.Writer
-func (l *Logger) Verbosity(m Level) *Logger {
+// Verbosity determines what Level of logging should be delivered to io.Writer.
+//
+// When tags are given Level m applies only to messages with those tags, ie
+// Verbosity(DEBUG, "db") shows debug messages tagged with db, db:pool and so
+// on, while others still use Logger verbosity. Tag segments are separated by
+// colon and * matches any single tag, so "*:client" matches http:client and
+// grpc:client. The most specific matching tag wins.
+func (l *Logger) Verbosity(m Level, tags ...string) *Logger {
 	n := l.new()
-	n.verbose = m
+	if len(tags) == 0 {
+		n.verbose = m
+		return n
+	}
+	n.rules = maps.Clone(n.rules)
+	if n.rules == nil {
+		n.rules = map[string]Level{}
+	}
+	for _, t := range tags {
+		n.rules[t] = m
+	}
 	return n
 }
 
+// Writer creates new instance of Logger which writes into io.Writer w
 func (l *Logger) Writer(w io.Writer) *Logger {
 	n := l.new()
 	n.writer = w
 	return n
 }
 
+// Encoder creates new Logger instance which renders messages with Encoder e
+// instead of built-in one chosen by Option
+func (l *Logger) Encoder(e Encoder) *Logger {
+	n := l.new()
+	n.encoder = e
+	return n
+}
+
 // Trace create new Logger instance
 func (l *Logger) Trace(depth int) *Logger {
 	n := l.new()
@@ -117,6 +209,26 @@ func (l *Logger) Trace(depth int) *Logger {
 	return n
 }
 
+// Stack creates new Logger instance which attaches stack trace of up to depth
+// frames to ERROR messages and messages with error argument, 0 disables it.
+// Frames for which any filter returns true are omitted, by default these are
+// runtime and standard library ones, see Std.
+func (l *Logger) Stack(depth int, filter ...func(Frame) bool) *Logger {
+	n := l.new()
+	n.stack, n.filter = depth, nil
+	if len(filter) > 0 {
+		n.filter = func(f Frame) bool {
+			for _, fn := range filter {
+				if fn(f) {
+					return true
+				}
+			}
+			return false
+		}
+	}
+	return n
+}
+
 // Options create new Logger instance
 func (l *Logger) Options(o Option) *Logger {
 	n := l.new()
@@ -158,37 +270,165 @@ func (l *Logger) Fatalf(text string, args ...any) {
 }
 
 func (l *Logger) write(text string, typ Level, args ...any) {
+	if typ != 0 && len(l.handlers) == 0 && l.ceiling() < typ {
+		return
+	}
 	if l.tag != "" {
 		text = l.tag + ":" + text
 	}
-	m := NewMessage(text, l.trace, args...)
+	m := newMessage(text, args...)
 	if typ != 0 {
 		m.Level = typ
 	}
+	if len(l.handlers) == 0 && l.threshold(m.Tags) < m.Level {
+		return
+	}
+	m.parse()
+	if l.traced() {
+		m.caller(l.trace)
+	}
+	if l.stack > 0 && (m.Level == ERROR || slices.ContainsFunc(args, isError)) {
+		m.Stack = l.frames(l.trace)
+	}
+	l.print(m)
+}
+
+// event logs Message with given tags and Level, it is used by middlewares
+// which are not called from user code, so Message has no location and tags are
+// never parsed from text
+func (l *Logger) event(tags []string, typ Level, text string, args ...any) {
+	if len(l.handlers) == 0 && l.threshold(tags) < typ {
+		return
+	}
+	m := Message{text: text, ARGS: args, Level: typ, Tags: tags, CreatedAt: time.Now()}
+	m.parse()
+	l.print(m)
+}
+
+// print passes Message m to handlers and writes it into io.Writer when its
+// Level is within verbosity
+func (l *Logger) print(m Message) {
+	switch {
+	case len(m.Data) == 0:
+		m.Data = l.data
+	case len(l.data) > 0:
+		m.Data = l.data.merge(m.Data)
+	}
 	for _, rfn := range l.handlers {
 		rfn(m)
 	}
-	if l.verbose < m.Level {
+	if l.threshold(m.Tags) < m.Level {
 		return
 	}
 
-	b, err := m.Render(l.option)
+	p := buffers.Get().(*[]byte)
+	defer buffers.Put(p)
+	b, err := l.encode((*p)[:0], m)
 	if err != nil {
-		log.Printf("sokool.log: message decode failed %s", err)
+		log.Printf("sokool.log: message encode failed %s", err)
+		return
 	}
-	if _, err = l.writer.Write(append(b, '\n')); err != nil {
+	if *p = append(b, '\n'); cap(*p) > 64<<10 {
+		defer func() { *p = make([]byte, 0, 1024) }()
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, err = l.writer.Write(*p); err != nil {
 		log.Printf("sokool.log: message write failed %s", err)
 	}
 }
 
+// tags returns tag names given to Logger.Tag without level names
+func (l *Logger) tags() []string {
+	var tt []string
+	for _, s := range strings.Split(l.tag, ":") {
+		if _, ok := levels[strings.TrimSpace(s)]; ok || s == "" {
+			continue
+		}
+		tt = append(tt, s)
+	}
+	return tt
+}
+
+// threshold returns verbosity for message with given tags, changes made by
+// Logger.Admin take precedence
+func (l *Logger) threshold(tags []string) Level {
+	if c := l.ctl.load(); c != nil {
+		if m := verbosity(c.rules, tags, 0); m != 0 {
+			return m
+		}
+		if c.verbose != 0 {
+			return c.verbose
+		}
+	}
+	return verbosity(l.rules, tags, l.verbose)
+}
+
+// ceiling returns the highest verbosity of Logger, messages above it are not
+// written no matter what tags they have
+func (l *Logger) ceiling() Level {
+	m := l.verbose
+	for _, v := range l.rules {
+		m = max(m, v)
+	}
+	if c := l.ctl.load(); c != nil {
+		m = max(m, c.verbose)
+		for _, v := range c.rules {
+			m = max(m, v)
+		}
+	}
+	return m
+}
+
+// traced tells if Message location is needed, either by handlers, custom
+// Encoder or Option
+func (l *Logger) traced() bool {
+	o := l.options()
+	return len(l.handlers) > 0 || l.encoder != nil || o&Trace != 0 ||
+		(o&JSON != 0 && o&(Date|Time|Levels|Tags|Trace|Properties) == 0)
+}
+
+// options returns Option used to render messages, changes made by
+// Logger.Admin take precedence
+func (l *Logger) options() Option {
+	if c := l.ctl.load(); c != nil && c.option != 0 {
+		return c.option
+	}
+	return l.option
+}
+
+// encode appends Message m rendered by Encoder to b
+func (l *Logger) encode(b []byte, m Message) ([]byte, error) {
+	o := l.options()
+	e := l.encoder
+	if e == nil {
+		e = encoder(o)
+	}
+	if a, ok := e.(Appender); ok {
+		return a.Append(b, m, o)
+	}
+	x, err := e.Encode(m, o)
+	return append(b, x...), err
+}
+
+// buffers used to render messages
+var buffers = sync.Pool{New: func() any { b := make([]byte, 0, 1024); return &b }}
+
 func (l *Logger) new() *Logger {
 	return &Logger{
 		writer:   l.writer,
 		verbose:  l.verbose,
+		rules:    l.rules,
 		tag:      l.tag,
 		handlers: l.handlers,
 		option:   l.option,
 		trace:    l.trace,
+		stack:    l.stack,
+		filter:   l.filter,
+		data:     l.data,
+		encoder:  l.encoder,
+		mu:       l.mu,
+		ctl:      l.ctl,
 	}
 }
 
@@ -56,3 +56,65 @@ func TestMessage_Fields(t *testing.T) {
 }
 
 type data map[string]any
+
+func TestLogger_With(t *testing.T) {
+	var b bytes.Buffer
+	l := log.New(&b, log.Levels|log.Tags|log.Properties).
+		With(log.Data{"service": "billing", "version": "1.2"}).
+		With(log.Data{"request_id": "abc"})
+
+	l.Printf("pay: charged %v", log.Data{"amount": 10})
+	if s := b.String(); s != "[INF] [pay] charged amount=10 request_id=abc service=billing version=1.2\n" {
+		t.Fatal(s)
+	}
+	b.Reset()
+	l.Options(log.JSON).Printf("charged")
+	if s := b.Bytes(); !bytes.Contains(s, []byte(`"service":"billing"`)) || !bytes.Contains(s, []byte(`"request_id":"abc"`)) {
+		t.Fatal(string(s))
+	}
+}
+
+func TestLogger_Encoder(t *testing.T) {
+	var b bytes.Buffer
+	e := log.EncoderFunc(func(m log.Message, o log.Option) ([]byte, error) {
+		return []byte(m.Level.String() + "|" + m.Tag(false) + "|" + m.Text(false, o&log.Properties != 0)), nil
+	})
+	log.New(&b, log.Properties).Encoder(e).Tag("db").Warnf("slow %v", log.Data{"ms": 30})
+	if s := b.String(); s != "WARNING|db|slow ms=30\n" {
+		t.Fatal(s)
+	}
+}
+
+func TestLogger_VerbosityTags(t *testing.T) {
+	var b bytes.Buffer
+	l := log.New(&b, log.Levels|log.Tags).
+		Verbosity(log.WARNING).
+		Verbosity(log.DEBUG, "db").
+		Verbosity(log.ERROR, "db:noisy", "*:client").
+		Verbosity(log.INFO, "http:client")
+
+	type scenario struct {
+		text   string
+		output string
+	}
+	cases := []scenario{
+		{"inf: hidden", ""},
+		{"wrn: shown", "[WRN] shown\n"},
+		{"db:dbg: shown", "[DBG] [db] shown\n"},
+		{"db:pool:dbg: inherited", "[DBG] [db:pool] inherited\n"},
+		{"db:noisy:wrn: hidden", ""},
+		{"grpc:client:wrn: hidden", ""},
+		{"http:client:inf: exact wins over wildcard", "[INF] [http:client] exact wins over wildcard\n"},
+		{"http:client:dbg: hidden", ""},
+	}
+	for _, c := range cases {
+		b.Reset()
+		if l.Printf(c.text); b.String() != c.output {
+			t.Fatalf("%s: expected `%s`, got `%s`", c.text, c.output, b.String())
+		}
+	}
+	b.Reset()
+	if l.Tag("db").Debugf("tag from logger"); b.String() != "[DBG] [db] tag from logger\n" {
+		t.Fatal(b.String())
+	}
+}
@@ -1,6 +1,7 @@
 package log
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"iter"
@@ -21,10 +22,21 @@ type Message struct {
 	Line       int
 	ARGS       []any
 	CreatedAt  time.Time
+	Data       Data
+	Stack      []Frame
 	attributes []int
 }
 
 func NewMessage(text string, deep int, args ...any) Message {
+	m := newMessage(text, args...)
+	m.parse()
+	m.caller(deep + 1)
+	return m
+}
+
+// newMessage creates Message with Level and Tags taken from text prefix, it
+// is enough to decide if Message is going to be written
+func newMessage(text string, args ...any) Message {
 	m := Message{text: text, ARGS: args, CreatedAt: time.Now(), Level: INFO}
 	if len(m.ARGS) == 1 {
 		if _, ok := m.ARGS[0].(error); ok {
@@ -33,7 +45,9 @@ func NewMessage(text string, deep int, args ...any) Message {
 	}
 
 	if i := m.index(m.text, false); i > 0 {
-		for _, s := range strings.Split(text[:i], ":") {
+		for p, n := text[:i], true; n; {
+			var s string
+			s, p, n = strings.Cut(p, ":")
 			l, ok := levels[strings.TrimSpace(s)]
 			if s == "" {
 				continue
@@ -45,11 +59,15 @@ func NewMessage(text string, deep int, args ...any) Message {
 			m.Tags = append(m.Tags, s)
 		}
 
-		if m.text = strings.Replace(m.text, text[:i], "", 1); m.text[0] == ':' {
+		if m.text = text[i:]; m.text[0] == ':' {
 			m.text = m.text[1:]
 		}
 	}
+	return m
+}
 
+// parse detects json in text and %v attributes
+func (m *Message) parse() {
 	m.text = strings.TrimSpace(m.text)
 	if i := m.index(m.text, true); i >= 0 {
 		var s Data
@@ -64,6 +82,10 @@ func NewMessage(text string, deep int, args ...any) Message {
 			if m.text[i] != '%' || c >= n {
 				continue
 			}
+			if m.text[i+1] == '%' {
+				i++
+				continue
+			}
 			if m.text[i+1] == 'v' {
 				m.attributes = append(m.attributes, c)
 			}
@@ -73,96 +95,139 @@ func NewMessage(text string, deep int, args ...any) Message {
 	}
 
 	m.text = strings.ReplaceAll(m.text, "%#v", "%s")
-	// todo call it when Trace option is enabled
-	if p, n, l, ok := runtime.Caller(deep + 1); ok {
+}
+
+// caller resolves File, Line and Func of function which called Message
+// constructor, skip is the number of frames to omit counting from caller of
+// caller
+func (m *Message) caller(skip int) {
+	if p, n, l, ok := runtime.Caller(skip + 1); ok {
 		m.File, m.Line, m.Func = n, l, runtime.FuncForPC(p).Name()
 	}
-
-	return m
 }
 
+// Render Message with built-in Encoder chosen by Option o
 func (m Message) Render(o Option) ([]byte, error) {
-	// todo decide based on Option what fields should be attached to json output
-	if o&JSON != 0 {
-		return m.MarshalJSON()
-	}
+	return encoder(o).Encode(m, o)
+}
 
-	var s string
-	var c = o&Colors != 0
-	if o&Date != 0 {
-		s += fmt.Sprintf("%s ", m.CreatedAt.Format("2006/01/02"))
-	}
-	if o&Time != 0 {
-		s += fmt.Sprintf("%s ", m.CreatedAt.Format("15:04:05.000000"))
-	}
-	if o&Levels != 0 {
-		s += fmt.Sprintf("[%s] ", m.Level.Render(true, c))
+// encoder returns built-in Encoder for Option o
+func encoder(o Option) Encoder {
+	if o&JSON != 0 {
+		return JSONEncoder{}
 	}
-	if t := m.Tag(c); o&Tags != 0 && t != "" {
-		s += fmt.Sprintf("[%s] ", t)
+	if o&Logfmt != 0 {
+		return LogfmtEncoder{}
 	}
-	s += m.Text(c, o&Properties != 0)
-
-	if o&Trace != 0 {
-		if l := m.Location(c); l != "" {
-			s += fmt.Sprintf(" %s", l)
-		}
-	}
-
-	return []byte(strings.TrimSpace(s)), nil
+	return TextEncoder{}
 }
 
 func (m Message) Text(colors, properties bool) string {
-	var n = len(m.ARGS)
-	var args []any
-	for i := range m.ARGS {
-		args = append(args, m.ARGS[i])
+	return string(m.appendText(nil, colors, properties))
+}
+
+// appendText appends text with arguments to b, see Message.Text
+func (m Message) appendText(b []byte, colors, properties bool) []byte {
+	if len(m.attributes) == 0 {
+		return collapse(fmt.Appendf(b, m.text, m.ARGS...), len(b))
 	}
-	for _, i := range m.attributes {
-		if i > n {
-			break
-		}
+	return m.appendFormat(b, func(v any) any {
 		if !properties {
-			args[i] = ""
-			continue
+			return ""
 		}
-		switch f := args[i].(type) {
-		case Data:
-			args[i] = f.properties(colors)
-		case map[string]any:
-			args[i] = Data(f).properties(colors)
+		switch f := v.(type) {
 		case string, int, int8, int16, int32, int64,
 			uint, uint8, uint16, uint32, uint64,
 			float32, float64,
 			complex64, complex128:
-			args[i] = f
+			return f
 		case []byte:
-			args[i] = string(f)
+			return string(f)
+		case error:
+			return f.Error()
 		default:
-			var s Data
-			b, _ := json.Marshal(f)
-			json.Unmarshal(b, &s)
-			args[i] = s.properties(colors)
+			d, _ := data(f)
+			return d.properties(colors)
+		}
+	})
+}
+
+// appendFormat appends text with arguments to b, where each %v attribute is
+// replaced by value returned from fn
+func (m Message) appendFormat(b []byte, fn func(any) any) []byte {
+	var n = len(m.ARGS)
+	var args = slices.Clone(m.ARGS)
+	for _, i := range m.attributes {
+		if i >= n {
+			break
 		}
+		args[i] = fn(args[i])
 	}
-	return strings.ReplaceAll(fmt.Sprintf(m.text, args...), "  ", " ")
+	return collapse(fmt.Appendf(b, m.text, args...), len(b))
 }
 
 func (m Message) Location(colors bool) string {
-	i := strings.LastIndex(m.File, "/")
-	s := fmt.Sprintf("%s:%d", m.File[i+1:], m.Line)
+	return string(m.appendLocation(nil, colors))
+}
+
+func (m Message) appendLocation(b []byte, colors bool) []byte {
+	if colors {
+		b = append(b, "\x1b[35;1m"...)
+	}
+	b = append(b, m.File[strings.LastIndex(m.File, "/")+1:]...)
+	b = append(b, ':')
+	b = strconv.AppendInt(b, int64(m.Line), 10)
 	if colors {
-		return fmt.Sprintf("\x1b[35;1m%s\x1b[0m", s)
+		b = append(b, "\x1b[0m"...)
 	}
-	return s
+	return b
+}
+
+// Trace renders Stack as multi line block, each Frame with function name and
+// indented location below
+func (m Message) Trace(colors bool) string {
+	return string(m.appendTrace(nil, colors))
+}
+
+func (m Message) appendTrace(b []byte, colors bool) []byte {
+	for _, f := range m.Stack {
+		b = append(b, "\n\t"...)
+		b = append(b, f.Func...)
+		b = append(b, "\n\t\t"...)
+		if colors {
+			b = append(b, "\x1b[35;1m"...)
+		}
+		b = append(b, f.File...)
+		b = append(b, ':')
+		b = strconv.AppendInt(b, int64(f.Line), 10)
+		if colors {
+			b = append(b, "\x1b[0m"...)
+		}
+	}
+	return b
 }
 
 func (m Message) Tag(colors bool) string {
-	s := strings.Join(m.Tags, ":")
-	if colors && s != "" {
-		return fmt.Sprintf("\x1b[34;1m%s\x1b[0m", s)
+	return string(m.appendTag(nil, colors))
+}
+
+func (m Message) appendTag(b []byte, colors bool) []byte {
+	if len(m.Tags) == 0 {
+		return b
+	}
+	if colors {
+		b = append(b, "\x1b[34;1m"...)
+	}
+	for i, t := range m.Tags {
+		if i > 0 {
+			b = append(b, ':')
+		}
+		b = append(b, t...)
+	}
+	if colors {
+		b = append(b, "\x1b[0m"...)
 	}
-	return s
+	return b
 }
 
 func (m Message) Type(colors bool) string {
@@ -183,30 +248,54 @@ func (m Message) MarshalText() ([]byte, error) {
 }
 
 func (m Message) Properties() Data {
+	d := Data{
+		"tag":   title(m.Tags),
+		"tags":  m.Tags,
+		"level": m.Level.String(),
+		"text":  strings.TrimSpace(m.Text(false, false)),
+		"file":  m.File,
+		"func":  m.Func,
+		"line":  m.Line,
+		"date":  m.CreatedAt,
+		"attr":  m.attrs(),
+	}
+	if len(m.Stack) > 0 {
+		d["stack"] = m.Stack
+	}
+	if err := m.error(); err != nil {
+		d["error"] = errorData(err)
+	}
+	for k, v := range m.Data {
+		if _, ok := d[k]; !ok {
+			d[k] = v
+		}
+	}
+	return d
+}
+
+// attrs returns %v arguments, errors are replaced with their messages
+func (m Message) attrs() []any {
 	var a []any
-	var n = len(m.ARGS)
-	var t string
 	for _, i := range m.attributes {
-		if i > n {
+		if i >= len(m.ARGS) {
 			break
 		}
+		if err, ok := m.ARGS[i].(error); ok {
+			a = append(a, err.Error())
+			continue
+		}
 		a = append(a, m.ARGS[i])
 	}
+	return a
+}
 
-	for i := range m.Tags {
-		t += fmt.Sprintf("%s", strings.Title(m.Tags[i]))
-	}
-	return Data{
-		"tag":   t,
-		"tags":  m.Tags,
-		"level": m.Level.String(),
-		"text":  m.Text(false, false),
-		"file":  m.File,
-		"func":  m.Func,
-		"line":  m.Line,
-		"date":  m.CreatedAt,
-		"attr":  a,
+// title joins tags, each starting with upper case letter
+func title(tags []string) string {
+	var t string
+	for i := range tags {
+		t += strings.Title(tags[i])
 	}
+	return t
 }
 
 func (m Message) index(text string, js bool) int {
@@ -226,12 +315,11 @@ func (m Message) index(text string, js bool) int {
 		}
 	}
 
-	var b []byte
 	var p int
 	if p = strings.Index(text, "{"); p == -1 {
 		p = strings.Index(text, "[")
 	}
-	if b = []byte(text); p == -1 || (!json.Valid(b[p:])) {
+	if p == -1 || !json.Valid([]byte(text[p:])) {
 		return i
 	}
 	if js {
@@ -245,26 +333,53 @@ func (m Message) index(text string, js bool) int {
 
 type Data map[string]any
 
+// data converts map or struct v into Data
+func data(v any) (Data, bool) {
+	switch f := v.(type) {
+	case Data:
+		return f, true
+	case map[string]any:
+		return f, true
+	}
+	var d Data
+	b, err := json.Marshal(v)
+	if err != nil || json.Unmarshal(b, &d) != nil {
+		return nil, false
+	}
+	return d, true
+}
+
 // properties returns a string of key=value pairs, optionally colored.
 func (d Data) properties(color bool, delim ...string) string {
-	var s strings.Builder
+	return string(d.appendProperties(nil, color, delim...))
+}
 
-	for n, v := range d.Flat(delim...) {
+// appendProperties appends key=value pairs to b, see Data.properties
+func (d Data) appendProperties(b []byte, color bool, delim ...string) []byte {
+	var n = len(b)
+	for k, v := range d.Flat(delim...) {
+		if color {
+			b = append(b, "\u001B[90;1m"...)
+			b = append(b, k...)
+			b = append(b, "\u001B[0m=\u001B[37;3m"...)
+		} else {
+			b = append(b, k...)
+			b = append(b, '=')
+		}
 		// Quote values if needed
 		if strings.Contains(v, " ") {
-			v = fmt.Sprintf(`"%s"`, v)
+			b = append(b, '"')
+			b = append(b, v...)
+			b = append(b, '"')
+		} else {
+			b = append(b, v...)
 		}
-
-		// Apply color if requested
 		if color {
-			n = fmt.Sprintf("\u001B[90;1m%s\u001B[0m", n)
-			v = fmt.Sprintf("\u001B[37;3m%s\u001B[0m", v)
+			b = append(b, "\u001B[0m"...)
 		}
-
-		s.WriteString(fmt.Sprintf("%s=%v ", n, v))
+		b = append(b, ' ')
 	}
-
-	return strings.TrimSpace(s.String())
+	return trim(b, n)
 }
 
 // Flat returns an iterator of flattened key-value pairs, sorted by key.
@@ -328,12 +443,30 @@ func (d Data) walk(p string, v any, delim string, yield func(string, string) boo
 			return true
 		}
 
-		return yield(p, fmt.Sprint(v))
+		return yield(p, str(v))
 	}
 
 	return true
 }
 
+// merge returns a copy of d with all keys from o, nested Data are merged
+// recursively rather than replaced.
+func (d Data) merge(o Data) Data {
+	n := make(Data, len(d)+len(o))
+	for k, v := range d {
+		n[k] = v
+	}
+	for k, v := range o {
+		a, ok1 := n[k].(Data)
+		b, ok2 := v.(Data)
+		if ok1 && ok2 {
+			v = a.merge(b)
+		}
+		n[k] = v
+	}
+	return n
+}
+
 func (d Data) join(a, b, delim string) string {
 	if a == "" {
 		return b
@@ -358,6 +491,48 @@ func (c color) text(s string) string {
 	return fmt.Sprintf("\x1b[38;5;%d;%d;%d1m%s", c.r, c.g, c.b, s)
 }
 
+// str formats v as fmt.Sprint does, without reflection for common types
+func str(v any) string {
+	switch x := v.(type) {
+	case string:
+		return x
+	case int:
+		return strconv.Itoa(x)
+	case int64:
+		return strconv.FormatInt(x, 10)
+	case float64:
+		return strconv.FormatFloat(x, 'g', -1, 64)
+	case bool:
+		return strconv.FormatBool(x)
+	}
+	return fmt.Sprint(v)
+}
+
+// collapse replaces double spaces with single one in b starting at n, as
+// strings.ReplaceAll(s, "  ", " ") does
+func collapse(b []byte, n int) []byte {
+	j := n
+	for i := n; i < len(b); i++ {
+		b[j] = b[i]
+		j++
+		if b[i] == ' ' && i+1 < len(b) && b[i+1] == ' ' {
+			i++
+		}
+	}
+	return b[:j]
+}
+
+// trim removes leading and trailing white spaces from b starting at n
+func trim(b []byte, n int) []byte {
+	t := bytes.TrimSpace(b[n:])
+	return append(b[:n], t...)
+}
+
+func isError(v any) bool {
+	_, ok := v.(error)
+	return ok
+}
+
 func isNumber(v any) bool {
 	switch v.(type) {
 	case int, int8, int16, int32, int64,
@@ -0,0 +1,124 @@
+package log
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"net"
+	"net/http"
+	"time"
+)
+
+// Middleware wraps http.Handler h and logs every request with http:access
+// tag, method, path, status code, bytes written, duration, remote address and
+// user agent. Server errors are logged with ERROR and client errors with
+// WARNING level.
+//
+// Request context carries Logger with request_id attribute taken from
+// X-Request-ID header or generated, use FromContext to log with it. When
+// request has traceparent header, context also carries SpanContext of its new
+// child span, so messages are correlated by trace_id and span_id.
+func (l *Logger) Middleware(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get("X-Request-ID")
+		if id == "" {
+			id = requestID()
+		}
+		w.Header().Set("X-Request-ID", id)
+		ctx := ContextWith(r.Context(), Data{"request_id": id})
+		if c, ok := SpanFromContext(Extract(ctx, r.Header)); ok {
+			ctx = ContextWithSpan(ctx, NewSpan(c))
+		}
+		ctx = NewContext(ctx, l)
+		rw := &response{ResponseWriter: w}
+		t := time.Now()
+		h.ServeHTTP(rw, r.WithContext(ctx))
+
+		var typ = INFO
+		switch {
+		case rw.status >= 500:
+			typ = ERROR
+		case rw.status >= 400:
+			typ = WARNING
+		}
+		tags := append(l.tags(), "http", "access")
+		l.context(ctx).event(tags, typ, "%s %s %d %v", r.Method, r.URL.Path, rw.code(), Data{
+			"bytes":      rw.bytes,
+			"duration":   time.Since(t).String(),
+			"remote":     r.RemoteAddr,
+			"user_agent": r.UserAgent(),
+		})
+	})
+}
+
+// response captures status code and number of bytes written by http.Handler
+type response struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+func (r *response) WriteHeader(code int) {
+	if r.status == 0 {
+		r.status = code
+	}
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *response) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	n, err := r.ResponseWriter.Write(b)
+	r.bytes += n
+	return n, err
+}
+
+func (r *response) Flush() {
+	if f, ok := r.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack takes over connection of http.Hijacker, ie for websocket upgrade,
+// request is logged with 101 status
+func (r *response) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := r.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, http.ErrNotSupported
+	}
+	c, w, err := h.Hijack()
+	if err == nil && r.status == 0 {
+		r.status = http.StatusSwitchingProtocols
+	}
+	return c, w, err
+}
+
+// ReadFrom copies src with io.ReaderFrom of underlying writer, so sendfile is
+// used when it is available
+func (r *response) ReadFrom(src io.Reader) (int64, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	n, err := io.Copy(r.ResponseWriter, src)
+	r.bytes += int(n)
+	return n, err
+}
+
+func (r *response) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+func (r *response) code() int {
+	if r.status == 0 {
+		return http.StatusOK
+	}
+	return r.status
+}
+
+func requestID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
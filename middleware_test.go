@@ -0,0 +1,117 @@
+package log_test
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/sokool/log"
+)
+
+func TestLogger_Middleware(t *testing.T) {
+	var b bytes.Buffer
+	l := log.New(&b, log.Levels|log.Tags|log.Properties)
+	h := l.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		log.FromContext(r.Context()).Tag("handler").Debugf("inside")
+		switch r.URL.Path {
+		case "/missing":
+			http.NotFound(w, r)
+		case "/broken":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			w.Write([]byte("hello"))
+		}
+	}))
+
+	type scenario struct {
+		path, id string
+		output   string
+	}
+	cases := []scenario{
+		{"/", "abc", `^\[DBG\] \[handler\] inside request_id=abc
+\[INF\] \[http:access\] GET / 200 bytes=5 duration=\S+ remote=192.0.2.1:1234 user_agent=test request_id=abc
+$`},
+		{"/missing", "", `^\[DBG\] \[handler\] inside request_id=[0-9a-f]{16}
+\[WRN\] \[http:access\] GET /missing 404 bytes=19 duration=\S+ remote=192.0.2.1:1234 user_agent=test request_id=[0-9a-f]{16}
+$`},
+		{"/broken", "x", `^\[DBG\] \[handler\] inside request_id=x
+\[ERR\] \[http:access\] GET /broken 500 bytes=0 duration=\S+ remote=192.0.2.1:1234 user_agent=test request_id=x
+$`},
+	}
+	for _, c := range cases {
+		b.Reset()
+		r := httptest.NewRequest(http.MethodGet, c.path, nil)
+		r.Header.Set("User-Agent", "test")
+		if c.id != "" {
+			r.Header.Set("X-Request-ID", c.id)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if !regexp.MustCompile(c.output).MatchString(b.String()) {
+			t.Fatalf("%s: unexpected output `%s`", c.path, b.String())
+		}
+		if w.Header().Get("X-Request-ID") == "" {
+			t.Fatal("expected X-Request-ID header")
+		}
+	}
+
+	b.Reset()
+	l.Options(log.Levels|log.Tags|log.Trace).Middleware(http.NotFoundHandler()).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	if s := b.String(); s != "[WRN] [http:access] GET / 404\n" {
+		t.Fatalf("expected access log without location, got `%s`", s)
+	}
+}
+
+func TestLogger_MiddlewareHijack(t *testing.T) {
+	var b bytes.Buffer
+	h := log.New(&b, log.Levels|log.Tags).Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/file" {
+			if _, ok := w.(io.ReaderFrom); !ok {
+				t.Error("expected io.ReaderFrom")
+			}
+			io.Copy(w, strings.NewReader("hello"))
+			return
+		}
+		c, rw, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer c.Close()
+		rw.WriteString("HTTP/1.1 101 Switching Protocols\r\nConnection: Upgrade\r\nUpgrade: websocket\r\n\r\n")
+		rw.Flush()
+	}))
+	// handler is done before its response is finished
+	done := make(chan struct{}, 2)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.ServeHTTP(w, r)
+		done <- struct{}{}
+	}))
+	defer srv.Close()
+
+	res, err := http.Get(srv.URL + "/file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	<-done
+	c, err := net.Dial("tcp", srv.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	c.Write([]byte("GET /ws HTTP/1.1\r\nHost: test\r\nConnection: Upgrade\r\nUpgrade: websocket\r\n\r\n"))
+	if s, _ := bufio.NewReader(c).ReadString('\n'); s != "HTTP/1.1 101 Switching Protocols\r\n" {
+		t.Fatalf("unexpected response %q", s)
+	}
+	<-done
+	if s := b.String(); s != "[INF] [http:access] GET /file 200\n[INF] [http:access] GET /ws 101\n" {
+		t.Fatalf("unexpected output `%s`", s)
+	}
+}
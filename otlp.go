@@ -0,0 +1,206 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"maps"
+	"math"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// OTLPConfig describes OpenTelemetry logs exporter, batching, retries and
+// delivery are configured by embedded HTTPConfig, its Encode is replaced.
+type OTLPConfig struct {
+	HTTPConfig
+
+	// Resource attributes describing source of messages, service.name is set
+	// to process name when missing
+	Resource Data
+
+	// Scope is name of instrumentation scope, github.com/sokool/log by default
+	Scope string
+}
+
+// NewOTLP creates HTTPSink which exports messages as OpenTelemetry log records
+// to OTLP/HTTP endpoint with JSON encoding, /v1/logs path is used when url has
+// none, ie http://localhost:4318.
+func NewOTLP(endpoint string, c OTLPConfig) *HTTPSink {
+	if u, err := url.Parse(endpoint); err == nil && strings.Trim(u.Path, "/") == "" {
+		u.Path = "/v1/logs"
+		endpoint = u.String()
+	}
+	if c.Scope == "" {
+		c.Scope = "github.com/sokool/log"
+	}
+	r := Data{"service.name": filepath.Base(os.Args[0])}.merge(c.Resource)
+	c.Encode = func(mm []Message) ([]byte, error) {
+		return json.Marshal(otlp(mm, r, c.Scope))
+	}
+	return NewHTTP(endpoint, c.HTTPConfig)
+}
+
+// otlp converts messages into ExportLogsServiceRequest
+func otlp(mm []Message, resource Data, scope string) any {
+	rr := make([]any, len(mm))
+	for i, m := range mm {
+		rr[i] = record(m)
+	}
+	return map[string]any{
+		"resourceLogs": []any{map[string]any{
+			"resource": map[string]any{"attributes": attributes(resource)},
+			"scopeLogs": []any{map[string]any{
+				"scope":      map[string]any{"name": scope},
+				"logRecords": rr,
+			}},
+		}},
+	}
+}
+
+// record converts Message m into LogRecord
+func record(m Message) map[string]any {
+	t, d := m.split(nil)
+	d = d.merge(m.Data)
+	if len(m.Tags) > 0 {
+		d = d.merge(Data{"tags": m.Tags})
+	}
+	if m.File != "" {
+		d = d.merge(Data{"code.filepath": m.File, "code.lineno": m.Line, "code.function": m.Func})
+	}
+	if err := m.error(); err != nil {
+		e := errorData(err)
+		d = d.merge(Data{"exception.message": e["message"], "exception.type": e["type"]})
+	}
+	if len(m.Stack) > 0 {
+		d = d.merge(Data{"exception.stacktrace": strings.TrimSpace(m.Trace(false))})
+	}
+	n, s := 9, "INFO"
+	switch m.Level {
+	case DEBUG:
+		n, s = 5, "DEBUG"
+	case WARNING:
+		n, s = 13, "WARN"
+	case ERROR:
+		n, s = 17, "ERROR"
+	}
+	r := map[string]any{
+		"timeUnixNano":         strconv.FormatInt(m.CreatedAt.UnixNano(), 10),
+		"observedTimeUnixNano": strconv.FormatInt(m.CreatedAt.UnixNano(), 10),
+		"severityNumber":       n,
+		"severityText":         s,
+		"body":                 map[string]any{"stringValue": string(t)},
+	}
+	// only W3C trace context identifiers are valid OTLP ids, other values stay
+	// in attributes
+	for _, x := range []struct {
+		key, field string
+		size       int
+	}{{"trace_id", "traceId", 32}, {"span_id", "spanId", 16}} {
+		if v, ok := d[x.key].(string); ok && len(v) == x.size && lowerHex(v) && strings.Trim(v, "0") != "" {
+			r[x.field] = v
+			delete(d, x.key)
+		}
+	}
+	if len(d) > 0 {
+		r["attributes"] = attributes(d)
+	}
+	return r
+}
+
+// attributes converts d into list of KeyValue sorted by key, nested maps and
+// structs are flattened with dot separated keys
+func attributes(d Data) []any {
+	var aa []any
+	var walk func(p string, v any)
+	walk = func(p string, v any) {
+		switch v.(type) {
+		case nil, string, bool, []string, []any:
+		default:
+			if x, ok := object(v); ok {
+				for _, k := range slices.Sorted(maps.Keys(x)) {
+					walk(d.join(p, k, "."), x[k])
+				}
+				return
+			}
+		}
+		aa = append(aa, map[string]any{"key": p, "value": value(v)})
+	}
+	walk("", d)
+	return aa
+}
+
+// value converts v into AnyValue
+func value(v any) map[string]any {
+	switch x := v.(type) {
+	case nil:
+		return map[string]any{}
+	case string:
+		return map[string]any{"stringValue": x}
+	case bool:
+		return map[string]any{"boolValue": x}
+	case float32:
+		return value(float64(x))
+	case float64:
+		return map[string]any{"doubleValue": x}
+	case uint:
+		return value(uint64(x))
+	case uint64:
+		// intValue is signed, larger numbers lose precision instead of range
+		if x > math.MaxInt64 {
+			return map[string]any{"doubleValue": float64(x)}
+		}
+		return map[string]any{"intValue": strconv.FormatUint(x, 10)}
+	case complex64, complex128:
+		return map[string]any{"stringValue": str(v)}
+	case json.Number:
+		if i, err := x.Int64(); err == nil {
+			return map[string]any{"intValue": strconv.FormatInt(i, 10)}
+		}
+		f, _ := x.Float64()
+		return map[string]any{"doubleValue": f}
+	case []string:
+		vv := make([]any, len(x))
+		for i, s := range x {
+			vv[i] = value(s)
+		}
+		return map[string]any{"arrayValue": map[string]any{"values": vv}}
+	case []any:
+		vv := make([]any, len(x))
+		for i, s := range x {
+			vv[i] = value(s)
+		}
+		return map[string]any{"arrayValue": map[string]any{"values": vv}}
+	}
+	if isNumber(v) {
+		return map[string]any{"intValue": str(v)}
+	}
+	if d, ok := object(v); ok {
+		return map[string]any{"kvlistValue": map[string]any{"values": attributes(d)}}
+	}
+	return map[string]any{"stringValue": str(v)}
+}
+
+// object returns v as Data like data does, numbers of structs are decoded as
+// json.Number, so integers do not become floats
+func object(v any) (Data, bool) {
+	switch f := v.(type) {
+	case Data:
+		return f, true
+	case map[string]any:
+		return f, true
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, false
+	}
+	var d Data
+	r := json.NewDecoder(bytes.NewReader(b))
+	if r.UseNumber(); r.Decode(&d) != nil {
+		return nil, false
+	}
+	return d, true
+}
@@ -0,0 +1,169 @@
+package log_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sokool/log"
+)
+
+func TestOTLP(t *testing.T) {
+	var mu sync.Mutex
+	var bodies [][]byte
+	var fails = 1
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if r.URL.Path != "/v1/logs" || r.Header.Get("Content-Type") != "application/json" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if fails > 0 {
+			fails--
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		b, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, b)
+	}))
+	defer srv.Close()
+
+	s := log.NewOTLP(srv.URL, log.OTLPConfig{
+		HTTPConfig: log.HTTPConfig{Frequency: time.Hour, Retries: 1, Backoff: time.Millisecond},
+		Resource:   log.Data{"service.name": "billing", "deployment": map[string]any{"environment": "test"}},
+	})
+	m := log.NewMessage("billing:invoice:err: payment %s failed %v %v", 0, "P-1", errors.New("card declined"), log.Data{"amount": 12.5, "user": map[string]any{"id": 7}})
+	m.CreatedAt = time.Unix(1700000000, 5)
+	m.Data = log.Data{"trace_id": "4bf92f3577b34da6a3ce929d0e0e4736", "span_id": "00f067aa0ba902b7"}
+	s.Handle(m)
+	s.Handle(log.NewMessage("dbg: cache miss", 0))
+	n := log.NewMessage("request", 0)
+	n.Data = log.Data{"trace_id": "req-42", "span_id": "0000000000000000"}
+	s.Handle(n)
+	if err := s.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(bodies) != 1 {
+		t.Fatalf("expected one request, got %d", len(bodies))
+	}
+
+	var req struct {
+		ResourceLogs []struct {
+			Resource  json.RawMessage
+			ScopeLogs []struct {
+				Scope      json.RawMessage
+				LogRecords []json.RawMessage
+			}
+		}
+	}
+	if err := json.Unmarshal(bodies[0], &req); err != nil {
+		t.Fatal(err)
+	}
+	l := req.ResourceLogs[0]
+	expected := `{"attributes":[{"key":"deployment.environment","value":{"stringValue":"test"}},{"key":"service.name","value":{"stringValue":"billing"}}]}`
+	if s := compact(t, l.Resource); s != expected {
+		t.Fatalf("expected resource\n%s\ngot\n%s", expected, s)
+	}
+	if s := compact(t, l.ScopeLogs[0].Scope); s != `{"name":"github.com/sokool/log"}` {
+		t.Fatalf("unexpected scope %s", s)
+	}
+	rr := l.ScopeLogs[0].LogRecords
+	if len(rr) != 3 {
+		t.Fatalf("expected 3 records, got %d", len(rr))
+	}
+	expected = `{"attributes":[` +
+		`{"key":"amount","value":{"doubleValue":12.5}},` +
+		`{"key":"code.filepath","value":{"stringValue":"` + m.File + `"}},` +
+		`{"key":"code.function","value":{"stringValue":"` + m.Func + `"}},` +
+		`{"key":"code.lineno","value":{"intValue":"` + strconv.Itoa(m.Line) + `"}},` +
+		`{"key":"exception.message","value":{"stringValue":"card declined"}},` +
+		`{"key":"exception.type","value":{"stringValue":"*errors.errorString"}},` +
+		`{"key":"tags","value":{"arrayValue":{"values":[{"stringValue":"billing"},{"stringValue":"invoice"}]}}},` +
+		`{"key":"user.id","value":{"intValue":"7"}}],` +
+		`"body":{"stringValue":"payment P-1 failed card declined"},` +
+		`"observedTimeUnixNano":"1700000000000000005",` +
+		`"severityNumber":17,"severityText":"ERROR",` +
+		`"spanId":"00f067aa0ba902b7",` +
+		`"timeUnixNano":"1700000000000000005",` +
+		`"traceId":"4bf92f3577b34da6a3ce929d0e0e4736"}`
+	if s := compact(t, rr[0]); s != expected {
+		t.Fatalf("expected record\n%s\ngot\n%s", expected, s)
+	}
+	var r struct {
+		SeverityNumber int
+		SeverityText   string
+	}
+	if json.Unmarshal(rr[1], &r); r.SeverityNumber != 5 || r.SeverityText != "DEBUG" {
+		t.Fatalf("unexpected severity %+v", r)
+	}
+	var x map[string]any
+	if json.Unmarshal(rr[2], &x); x["traceId"] != nil || x["spanId"] != nil || len(x["attributes"].([]any)) != 5 {
+		t.Fatalf("expected invalid ids kept as attributes, got %s", rr[2])
+	}
+}
+
+func TestOTLP_Values(t *testing.T) {
+	var body []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+	}))
+	defer srv.Close()
+
+	s := log.NewOTLP(srv.URL, log.OTLPConfig{HTTPConfig: log.HTTPConfig{Frequency: time.Hour}})
+	m := log.NewMessage("values", 0)
+	m.File, m.Data = "", log.Data{
+		"complex": complex(1, 2),
+		"float":   12.0,
+		"int":     int8(-3),
+		"large":   uint64(math.MaxUint64),
+		"struct":  struct{ ID, Big uint64 }{7, math.MaxUint64},
+	}
+	s.Handle(m)
+	if err := s.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	var req struct {
+		ResourceLogs []struct {
+			ScopeLogs []struct {
+				LogRecords []struct{ Attributes json.RawMessage }
+			}
+		}
+	}
+	if err := json.Unmarshal(body, &req); err != nil {
+		t.Fatal(err)
+	}
+	expected := `[{"key":"complex","value":{"stringValue":"(1+2i)"}},` +
+		`{"key":"float","value":{"doubleValue":12}},` +
+		`{"key":"int","value":{"intValue":"-3"}},` +
+		`{"key":"large","value":{"doubleValue":18446744073709552000}},` +
+		`{"key":"struct.Big","value":{"doubleValue":18446744073709552000}},` +
+		`{"key":"struct.ID","value":{"intValue":"7"}}]`
+	if a := compact(t, req.ResourceLogs[0].ScopeLogs[0].LogRecords[0].Attributes); a != expected {
+		t.Fatalf("expected attributes\n%s\ngot\n%s", expected, a)
+	}
+}
+
+// compact returns JSON b with keys sorted and without white spaces
+func compact(t *testing.T, b []byte) string {
+	var v any
+	if err := json.Unmarshal(b, &v); err != nil {
+		t.Fatal(err)
+	}
+	var w bytes.Buffer
+	e := json.NewEncoder(&w)
+	e.SetEscapeHTML(false)
+	if err := e.Encode(v); err != nil {
+		t.Fatal(err)
+	}
+	return string(bytes.TrimSpace(w.Bytes()))
+}
@@ -0,0 +1,133 @@
+package log
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// Frame is a single function call in stack trace
+type Frame struct {
+	Func string `json:"func"`
+	File string `json:"file"`
+	Line int    `json:"line"`
+}
+
+// Recover logs panic with ERROR level, panic value and stack trace, use it
+// with defer in goroutines which should survive panic
+//
+//	defer lgr.Recover()
+func (l *Logger) Recover() {
+	if v := recover(); v != nil {
+		l.panic(v)
+	}
+}
+
+// Repanic works as Recover, but panics again with the same value after it
+// is logged
+//
+//	defer lgr.Repanic()
+func (l *Logger) Repanic() {
+	if v := recover(); v != nil {
+		l.panic(v)
+		panic(v)
+	}
+}
+
+// Recovery wraps http.Handler h, panics are logged as in Recover and client
+// receives 500 Internal Server Error. http.ErrAbortHandler is not logged and
+// panics again.
+func (l *Logger) Recovery(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			v := recover()
+			if v == nil {
+				return
+			}
+			if v == http.ErrAbortHandler {
+				panic(v)
+			}
+			l.context(r.Context()).panic(v)
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		}()
+		h.ServeHTTP(w, r)
+	})
+}
+
+// frames returns stack trace limited and filtered as set by Logger.Stack, skip
+// is the number of frames to omit counting from caller of frames
+func (l *Logger) frames(skip int) []Frame {
+	d, f := l.stack, l.filter
+	if d <= 0 {
+		d = 64
+	}
+	if f == nil {
+		f = Std
+	}
+	return stack(skip+1, d, f)
+}
+
+// panic logs recovered value v, must be called directly from deferred function
+func (l *Logger) panic(v any) {
+	m := Message{
+		text:      "panic: %s",
+		ARGS:      []any{fmt.Sprint(v)},
+		Level:     ERROR,
+		Tags:      l.tags(),
+		CreatedAt: time.Now(),
+		Stack:     l.frames(2),
+	}
+	if len(m.Stack) > 0 {
+		m.File, m.Line, m.Func = m.Stack[0].File, m.Stack[0].Line, m.Stack[0].Func
+	}
+	l.print(m)
+}
+
+// stack returns up to depth call frames of goroutine, skip is the number of
+// frames to omit counting from caller of stack, frames for which filter
+// returns true are omitted
+func stack(skip, depth int, filter func(Frame) bool) []Frame {
+	pc := make([]uintptr, 64)
+	ff := runtime.CallersFrames(pc[:runtime.Callers(skip+2, pc)])
+	var s []Frame
+	for len(s) < depth {
+		f, more := ff.Next()
+		if r := (Frame{Func: f.Function, File: f.File, Line: f.Line}); !filter(r) {
+			s = append(s, r)
+		}
+		if !more {
+			break
+		}
+	}
+	return s
+}
+
+// Std tells if Frame belongs to runtime or standard library
+func Std(f Frame) bool {
+	if strings.HasPrefix(f.Func, "runtime.") {
+		return true
+	}
+	if goroot != "" {
+		return strings.HasPrefix(f.File, goroot)
+	}
+	p, _, _ := strings.Cut(f.File, "/")
+	return !strings.Contains(p, ".")
+}
+
+// goroot is the source directory of standard library, empty when binary is
+// built with -trimpath, then frames from paths without domain are assumed to
+// be standard library ones
+var goroot = func() string {
+	f := runtime.FuncForPC(reflect.ValueOf(fmt.Sprint).Pointer())
+	if f == nil {
+		return ""
+	}
+	s, _ := f.FileLine(f.Entry())
+	if !strings.HasSuffix(s, "/fmt/print.go") {
+		return ""
+	}
+	return strings.TrimSuffix(s, "fmt/print.go")
+}()
@@ -0,0 +1,111 @@
+package log_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	"github.com/sokool/log"
+)
+
+func TestLogger_Recover(t *testing.T) {
+	var b bytes.Buffer
+	l := log.New(&b, log.Levels|log.Tags|log.Trace).Tag("worker")
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer l.Recover()
+		explode()
+	}()
+	<-done
+
+	r := `^\[ERR\] \[worker\] panic: boom recover_test\.go:\d+
+	github\.com/sokool/log_test\.explode
+		.+/recover_test\.go:\d+
+	github\.com/sokool/log_test\.TestLogger_Recover\.func1
+		.+/recover_test\.go:\d+
+$`
+	if !regexp.MustCompile(r).MatchString(b.String()) {
+		t.Fatal(b.String())
+	}
+
+	b.Reset()
+	func() {
+		defer func() {
+			if v := recover(); v != "boom" {
+				t.Fatalf("expected repanic, got %v", v)
+			}
+		}()
+		defer l.Options(log.JSON).Repanic()
+		explode()
+	}()
+	var m struct {
+		Level string
+		Text  string
+		Tags  []string
+		Stack []log.Frame
+	}
+	if err := json.Unmarshal(b.Bytes(), &m); err != nil {
+		t.Fatal(err)
+	}
+	if m.Level != "ERROR" || m.Text != "panic: boom" || m.Tags[0] != "worker" || m.Stack[0].Func != "github.com/sokool/log_test.explode" {
+		t.Fatal(b.String())
+	}
+}
+
+func TestLogger_Recovery(t *testing.T) {
+	var b bytes.Buffer
+	l := log.New(&b, log.Levels|log.Properties)
+	h := l.Middleware(l.Recovery(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { explode() })))
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("X-Request-ID", "abc")
+	h.ServeHTTP(w, r)
+	if w.Code != http.StatusInternalServerError {
+		t.Fatal(w.Code)
+	}
+	if !regexp.MustCompile(`^\[ERR\] panic: boom request_id=abc\n\tgithub\.com/sokool/log_test\.explode\n`).MatchString(b.String()) {
+		t.Fatal(b.String())
+	}
+}
+
+func explode() {
+	panic("boom")
+}
+
+func TestLogger_Stack(t *testing.T) {
+	var b bytes.Buffer
+	l := log.New(&b, log.Levels).Stack(2)
+
+	l.Infof("no stack")
+	l.Warnf("failed %s", errors.New("oops"))
+	l.Errorf("deep")
+	r := `^\[INF\] no stack
+\[WRN\] failed oops
+	github\.com/sokool/log_test\.TestLogger_Stack
+		.+/recover_test\.go:\d+
+\[ERR\] deep
+	github\.com/sokool/log_test\.TestLogger_Stack
+		.+/recover_test\.go:\d+
+$`
+	if !regexp.MustCompile(r).MatchString(b.String()) {
+		t.Fatal(b.String())
+	}
+
+	b.Reset()
+	l.Stack(10, func(f log.Frame) bool { return false }).Options(log.JSON).Errorf("all")
+	var m struct{ Stack []log.Frame }
+	if err := json.Unmarshal(b.Bytes(), &m); err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Stack) < 2 || m.Stack[0].Func != "github.com/sokool/log_test.TestLogger_Stack" || m.Stack[1].Func != "testing.tRunner" {
+		t.Fatal(b.String())
+	}
+	if !log.Std(m.Stack[1]) || log.Std(m.Stack[0]) {
+		t.Fatal("expected testing.tRunner as standard library frame")
+	}
+}
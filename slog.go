@@ -0,0 +1,129 @@
+package log
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// SlogHandler implements slog.Handler on top of Logger, so records produced
+// by slog.Logger are rendered and delivered exactly like Logger.Printf does.
+type SlogHandler struct {
+	logger *Logger
+	groups []string
+}
+
+// NewSlogHandler creates slog.Handler which writes every slog.Record through
+// Logger l, use it with slog.New(log.NewSlogHandler(l))
+func NewSlogHandler(l *Logger) *SlogHandler {
+	return &SlogHandler{logger: l}
+}
+
+func (h *SlogHandler) Enabled(_ context.Context, l slog.Level) bool {
+	return len(h.logger.handlers) > 0 || h.logger.threshold(h.logger.tags()) >= level(l)
+}
+
+func (h *SlogHandler) Handle(ctx context.Context, r slog.Record) error {
+	m := Message{
+		text:      strings.ReplaceAll(r.Message, "%", "%%"),
+		Level:     level(r.Level),
+		CreatedAt: r.Time,
+	}
+	if m.CreatedAt.IsZero() {
+		m.CreatedAt = time.Now()
+	}
+	m.Tags = h.logger.tags()
+	d := Data{}
+	r.Attrs(func(a slog.Attr) bool {
+		attr(d, a)
+		return true
+	})
+	if d = group(h.groups, d); len(d) > 0 {
+		m.text, m.ARGS, m.attributes = strings.TrimSpace(m.text+" %v"), []any{d}, []int{0}
+	}
+	if h.logger.stack > 0 && m.Level == ERROR {
+		m.Stack = h.logger.frames(1)
+	}
+	if r.PC != 0 {
+		f, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+		m.File, m.Line, m.Func = f.File, f.Line, f.Function
+	}
+	h.logger.context(ctx).print(m)
+	return nil
+}
+
+// WithAttrs returns SlogHandler with attributes as default Logger.With data
+func (h *SlogHandler) WithAttrs(aa []slog.Attr) slog.Handler {
+	d := Data{}
+	for _, a := range aa {
+		attr(d, a)
+	}
+	return &SlogHandler{logger: h.logger.With(group(h.groups, d)), groups: h.groups}
+}
+
+// WithGroup returns SlogHandler which nests all following attributes in Data
+// under name
+func (h *SlogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &SlogHandler{logger: h.logger, groups: append(h.groups[:len(h.groups):len(h.groups)], name)}
+}
+
+// level maps slog.Level onto closest Level
+func level(l slog.Level) Level {
+	switch {
+	case l < slog.LevelInfo:
+		return DEBUG
+	case l < slog.LevelWarn:
+		return INFO
+	case l < slog.LevelError:
+		return WARNING
+	default:
+		return ERROR
+	}
+}
+
+// attr writes slog.Attr a into Data d, groups become nested Data
+func attr(d Data, a slog.Attr) {
+	v := a.Value.Resolve()
+	if v.Kind() != slog.KindGroup {
+		if a.Key == "" {
+			return
+		}
+		switch x := v.Any().(type) {
+		case error:
+			d[a.Key] = x.Error()
+		default:
+			d[a.Key] = x
+		}
+		return
+	}
+	g := Data{}
+	for _, a := range v.Group() {
+		attr(g, a)
+	}
+	if len(g) == 0 {
+		return
+	}
+	if a.Key == "" {
+		for k, v := range g {
+			d[k] = v
+		}
+		return
+	}
+	d[a.Key] = g
+}
+
+// group nests Data d under given names
+func group(names []string, d Data) Data {
+	if len(d) == 0 {
+		return d
+	}
+	for i := len(names) - 1; i >= 0; i-- {
+		d = Data{names[i]: d}
+	}
+	return d
+}
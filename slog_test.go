@@ -0,0 +1,46 @@
+package log_test
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/sokool/log"
+)
+
+func TestSlogHandler(t *testing.T) {
+	var a, b bytes.Buffer
+	o := log.Levels | log.Tags | log.Properties
+	l := log.New(&a, o).Tag("app").With(log.Data{"service": "api"})
+	s := slog.New(log.NewSlogHandler(log.New(&b, o).Tag("app").With(log.Data{"service": "api"})))
+
+	l.Warnf("disk %d%% full %v", 90, log.Data{"disk": "sda", "mount": log.Data{"path": "/var"}})
+	s.Warn("disk 90% full", "disk", "sda", slog.Group("mount", "path", "/var"))
+	if a.String() != b.String() {
+		t.Fatalf("expected `%s`, got `%s`", a.String(), b.String())
+	}
+
+	b.Reset()
+	s.With("user", 7).WithGroup("req").With("id", "x1").Debug("done", "ms", 3)
+	if s := b.String(); s != "[DBG] [app] done req.ms=3 req.id=x1 service=api user=7\n" {
+		t.Fatal(s)
+	}
+
+	b.Reset()
+	s = slog.New(log.NewSlogHandler(log.New(&b, o).Verbosity(log.WARNING)))
+	if s.Info("hidden"); b.Len() != 0 {
+		t.Fatal(b.String())
+	}
+	if s.Error("failed", "err", bytes.ErrTooLarge); b.String() != "[ERR] failed err=\"bytes.Buffer: too large\"\n" {
+		t.Fatal(b.String())
+	}
+}
+
+func TestSlogHandler_Stack(t *testing.T) {
+	var b bytes.Buffer
+	slog.New(log.NewSlogHandler(log.New(&b, log.Levels).Stack(1))).Error("failed")
+	if s := b.String(); !strings.HasPrefix(s, "[ERR] failed\n\tgithub.com/sokool/log_test.TestSlogHandler_Stack\n") {
+		t.Fatal(s)
+	}
+}
@@ -0,0 +1,103 @@
+package log
+
+import (
+	"errors"
+	"net"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// socket is connection of sinks sending every message in single write, it is
+// established again after write fails. Connecting is done without holding
+// lock, messages sent meanwhile or within redial period after failed attempt
+// are dropped.
+type socket struct {
+	network string
+	address string
+	timeout time.Duration
+	redial  time.Duration
+	mu      sync.Mutex
+	conn    net.Conn
+	closed  bool
+	dialing bool
+	retry   time.Time
+}
+
+// dial creates socket connected to address on named network, timeout limits
+// both connecting and every write, redial is period after failed connection
+// attempt in which messages are dropped instead of connecting again
+func dial(network, address string, timeout, redial time.Duration) (*socket, error) {
+	c, err := net.DialTimeout(network, address, timeout)
+	if err != nil {
+		return nil, err
+	}
+	return &socket{network: network, address: address, timeout: timeout, redial: redial, conn: c}, nil
+}
+
+// write sends p, ErrDropped is returned when connection is being established
+// or redial period has not passed yet. Datagram too large for socket is
+// rejected with syscall.EMSGSIZE and connection is kept.
+func (s *socket) write(p []byte) error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return ErrClosed
+	}
+	if s.conn != nil {
+		err := s.send(s.conn, p)
+		if err == nil || errors.Is(err, syscall.EMSGSIZE) {
+			s.mu.Unlock()
+			return err
+		}
+		s.conn.Close()
+		s.conn = nil
+	}
+	if s.dialing || time.Now().Before(s.retry) {
+		s.mu.Unlock()
+		return ErrDropped
+	}
+	s.dialing = true
+	s.mu.Unlock()
+
+	c, err := net.DialTimeout(s.network, s.address, s.timeout)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.dialing = false; err != nil {
+		s.retry = time.Now().Add(s.redial)
+		return err
+	}
+	if s.closed {
+		c.Close()
+		return ErrClosed
+	}
+	if err = s.send(c, p); err != nil {
+		c.Close()
+		s.retry = time.Now().Add(s.redial)
+		return err
+	}
+	s.conn = c
+	return nil
+}
+
+func (s *socket) send(c net.Conn, p []byte) error {
+	c.SetWriteDeadline(time.Now().Add(s.timeout))
+	_, err := c.Write(p)
+	return err
+}
+
+// close closes connection, write after close returns ErrClosed
+func (s *socket) close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return ErrClosed
+	}
+	s.closed = true
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
@@ -0,0 +1,211 @@
+package log
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SyslogFormat is a protocol of messages sent by SyslogSink
+type SyslogFormat int
+
+const (
+	// RFC5424 is the current syslog protocol with structured data
+	RFC5424 SyslogFormat = iota
+
+	// RFC3164 is the legacy BSD syslog protocol
+	RFC3164
+)
+
+// SyslogConfig describes messages sent by SyslogSink, zero values are replaced
+// with defaults described next to each field.
+type SyslogConfig struct {
+	// Format of messages, RFC5424 by default
+	Format SyslogFormat
+
+	// App is APP-NAME of messages without tags, process name by default
+	App string
+
+	// Hostname of messages, os.Hostname by default
+	Hostname string
+
+	// Facility of messages, 1 (user-level) by default
+	Facility int
+
+	// ID of RFC5424 structured data element with Data attributes,
+	// data@32473 by default
+	ID string
+
+	// Timeout of connecting to syslog server and sending single message, 5
+	// seconds by default
+	Timeout time.Duration
+
+	// Redial is the period after failed connection attempt in which messages
+	// are dropped instead of connecting again, 1 second by default
+	Redial time.Duration
+
+	// Errors receives every delivery error, by default they are printed by
+	// standard log package
+	Errors func(error)
+}
+
+// SyslogSink sends messages to syslog server over udp, tcp or unix sockets,
+// use its Handle method as Logger Handler. Messages sent over stream
+// connections are framed with octet counting.
+type SyslogSink struct {
+	network string
+	conf    SyslogConfig
+	socket  *socket
+}
+
+// NewSyslog creates SyslogSink connected to address on named network, ie
+// "udp", "tcp", "unix" or "unixgram"
+func NewSyslog(network, address string, c SyslogConfig) (*SyslogSink, error) {
+	if c.App == "" {
+		c.App = filepath.Base(os.Args[0])
+	}
+	if c.Hostname == "" {
+		c.Hostname, _ = os.Hostname()
+	}
+	if c.Facility == 0 {
+		c.Facility = 1
+	}
+	if c.ID == "" {
+		c.ID = "data@32473"
+	}
+	if c.Timeout == 0 {
+		c.Timeout = 5 * time.Second
+	}
+	if c.Redial == 0 {
+		c.Redial = time.Second
+	}
+	if c.Errors == nil {
+		c.Errors = func(err error) { log.Printf("sokool.log: syslog sink %s", err) }
+	}
+	k, err := dial(network, address, c.Timeout, c.Redial)
+	if err != nil {
+		return nil, err
+	}
+	return &SyslogSink{network: network, conf: c, socket: k}, nil
+}
+
+// Handle sends Message m. When connection fails it is established again,
+// messages handled while server is unreachable are reported as ErrDropped,
+// new connection is attempted once per Redial period.
+func (s *SyslogSink) Handle(m Message) {
+	p := buffers.Get().(*[]byte)
+	defer buffers.Put(p)
+	b := s.append((*p)[:0], m)
+	if *p = b; s.framed() {
+		// octet counting: length of message followed by space, frame is built
+		// behind message in the same buffer
+		n := len(b)
+		*p = append(append(strconv.AppendInt(b, int64(n), 10), ' '), b...)
+		b = (*p)[n:]
+	}
+	if cap(*p) > 64<<10 {
+		defer func() { *p = make([]byte, 0, 1024) }()
+	}
+	if err := s.socket.write(b); err != nil {
+		s.conf.Errors(err)
+	}
+}
+
+// Close closes connection to syslog server, messages handled after Close are
+// reported as ErrClosed
+func (s *SyslogSink) Close() error {
+	return s.socket.close()
+}
+
+// append appends Message m in configured format to b
+func (s *SyslogSink) append(b []byte, m Message) []byte {
+	app := s.conf.App
+	if len(m.Tags) > 0 {
+		app = m.Tags[0]
+	}
+	t, d := m.split(nil)
+	if err := m.error(); err != nil {
+		d = d.merge(Data{"error": errorData(err)})
+	}
+	d = d.merge(m.Data)
+
+	b = append(b, '<')
+	b = strconv.AppendInt(b, int64(s.conf.Facility*8+severity(m.Level)), 10)
+	b = append(b, '>')
+	if s.conf.Format == RFC3164 {
+		b = m.CreatedAt.AppendFormat(b, "Jan _2 15:04:05 ")
+		b = append(header(b, s.conf.Hostname, 255), ' ')
+		b = append(header(b, app, 32), '[')
+		b = strconv.AppendInt(b, int64(os.Getpid()), 10)
+		b = append(b, "]: "...)
+		n := len(b)
+		b = append(b, t...)
+		if len(d) > 0 {
+			b = append(b, ' ')
+		}
+		for k, v := range d.Flat() {
+			b = logfmt(b, k, v)
+		}
+		b = trim(b, n)
+	} else {
+		b = m.CreatedAt.AppendFormat(append(b, "1 "...), "2006-01-02T15:04:05.000000Z07:00 ")
+		b = append(header(b, s.conf.Hostname, 255), ' ')
+		b = append(header(b, app, 48), ' ')
+		b = strconv.AppendInt(b, int64(os.Getpid()), 10)
+		b = append(b, " - "...)
+		if len(d) == 0 {
+			b = append(b, '-')
+		} else {
+			b = append(header(append(b, '['), s.conf.ID, 32), ' ')
+			for k, v := range d.Flat() {
+				b = append(header(b, k, 32), '=', '"')
+				b = append(b, escape.Replace(v)...)
+				b = append(b, '"', ' ')
+			}
+			b[len(b)-1] = ']'
+		}
+		b = append(append(b, ' '), t...)
+	}
+	return b
+}
+
+// framed tells if messages are sent over stream connection, where they need
+// octet counting frame
+func (s *SyslogSink) framed() bool {
+	return !strings.HasPrefix(s.network, "udp") && s.network != "unixgram"
+}
+
+// escape of RFC5424 structured data values
+var escape = strings.NewReplacer(`"`, `\"`, `\`, `\\`, `]`, `\]`)
+
+// severity returns syslog severity of Level m
+func severity(m Level) int {
+	switch m {
+	case ERROR:
+		return 3
+	case WARNING:
+		return 4
+	case DEBUG:
+		return 7
+	}
+	return 6
+}
+
+// header appends syslog header field v to b, up to n printable ascii
+// characters, - when v is empty
+func header(b []byte, v string, n int) []byte {
+	if v == "" {
+		return append(b, '-')
+	}
+	for i := 0; i < len(v) && i < n; i++ {
+		if c := v[i]; c > ' ' && c < 0x7f && c != '=' && c != ']' && c != '"' {
+			b = append(b, c)
+		} else {
+			b = append(b, '_')
+		}
+	}
+	return b
+}
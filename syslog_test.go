@@ -0,0 +1,216 @@
+package log_test
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sokool/log"
+)
+
+func TestSyslogSink_Format(t *testing.T) {
+	type scenario struct {
+		description string
+		format      log.SyslogFormat
+		input       string
+		args        []any
+		output      string
+	}
+	pid := os.Getpid()
+	cases := []scenario{
+		{
+			description: "rfc5424 with structured data",
+			input:       "db:err: query %s failed %v",
+			args:        []any{"users", log.Data{"table": "users", "query": map[string]any{"rows": 5}, "note": `a "quoted] \ value`}},
+			output:      fmt.Sprintf(`<11>1 2024-05-01T10:20:30.000123Z host db %d - [data@32473 note="a \"quoted\] \\ value" query.rows="5" table="users"] query users failed`, pid),
+		},
+		{
+			description: "rfc5424 without tags and data",
+			input:       "wrn: disk almost full",
+			output:      fmt.Sprintf(`<12>1 2024-05-01T10:20:30.000123Z host app %d - - disk almost full`, pid),
+		},
+		{
+			description: "rfc5424 debug",
+			input:       "cache:dbg: miss",
+			output:      fmt.Sprintf(`<15>1 2024-05-01T10:20:30.000123Z host cache %d - - miss`, pid),
+		},
+		{
+			description: "rfc3164",
+			format:      log.RFC3164,
+			input:       "http: request %s handled %v",
+			args:        []any{"GET", log.Data{"status": 200}},
+			output:      fmt.Sprintf(`<14>May  1 10:20:30 host http[%d]: request GET handled status=200`, pid),
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			l, err := net.ListenPacket("udp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer l.Close()
+			s, err := log.NewSyslog("udp", l.LocalAddr().String(), log.SyslogConfig{Format: c.format, App: "app", Hostname: "host"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer s.Close()
+			m := log.NewMessage(c.input, 0, c.args...)
+			m.CreatedAt = time.Date(2024, 5, 1, 10, 20, 30, 123456, time.UTC)
+			s.Handle(m)
+
+			b := make([]byte, 2048)
+			l.SetReadDeadline(time.Now().Add(time.Second))
+			n, _, err := l.ReadFrom(b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(b[:n]) != c.output {
+				t.Fatalf("expected\n%s\ngot\n%s", c.output, b[:n])
+			}
+		})
+	}
+}
+
+func TestSyslogSink_TCP(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	lines := make(chan string, 10)
+	go func() {
+		for {
+			c, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer c.Close()
+				r := bufio.NewReader(c)
+				for {
+					s, err := r.ReadString(' ')
+					if err != nil {
+						return
+					}
+					n, _ := strconv.Atoi(strings.TrimSpace(s))
+					b := make([]byte, n)
+					if _, err = io.ReadFull(r, b); err != nil {
+						return
+					}
+					lines <- string(b)
+				}
+			}()
+		}
+	}()
+
+	s, err := log.NewSyslog("tcp", l.Addr().String(), log.SyslogConfig{Hostname: "host"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	lgr := log.New(io.Discard).Handlers(s.Handle)
+	lgr.Errorf("api: multi\nline message")
+	lgr.Infof("api: second message")
+	for _, x := range []string{"multi\nline message", "second message"} {
+		select {
+		case m := <-lines:
+			if !strings.HasSuffix(m, " api "+strconv.Itoa(os.Getpid())+" - - "+x) {
+				t.Fatalf("unexpected message %q", m)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("message not received")
+		}
+	}
+	if err = s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err = s.Close(); !errors.Is(err, log.ErrClosed) {
+		t.Fatalf("expected ErrClosed, got %v", err)
+	}
+}
+
+func TestSyslogSink_Redial(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := l.Addr().String()
+	var ee []error
+	s, err := log.NewSyslog("tcp", a, log.SyslogConfig{Timeout: time.Second, Redial: 100 * time.Millisecond, Errors: func(err error) { ee = append(ee, err) }})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	c, err := l.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Close()
+	l.Close()
+
+	// unreachable server is dialed once, next messages are dropped
+	for i := 0; i < 20; i++ {
+		s.Handle(log.NewMessage("lost", 0))
+	}
+	var dropped int
+	for _, err = range ee {
+		if errors.Is(err, log.ErrDropped) {
+			dropped++
+		}
+	}
+	if len(ee) == 0 || len(ee)-dropped > 2 {
+		t.Fatalf("expected single connection error followed by dropped messages, got %v", ee)
+	}
+
+	if l, err = net.Listen("tcp", a); err != nil {
+		t.Skip(err)
+	}
+	defer l.Close()
+	time.Sleep(150 * time.Millisecond)
+	s.Handle(log.NewMessage("again", 0))
+	if c, err = l.Accept(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	b := make([]byte, 1024)
+	c.SetReadDeadline(time.Now().Add(time.Second))
+	if n, _ := c.Read(b); !strings.HasSuffix(string(b[:n]), " again") {
+		t.Fatalf("expected message over new connection, got %q", b[:n])
+	}
+}
+
+func TestSyslogSink_Unix(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("unix sockets are not supported")
+	}
+	a := filepath.Join(t.TempDir(), "log.sock")
+	l, err := net.ListenPacket("unixgram", a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	s, err := log.NewSyslog("unixgram", a, log.SyslogConfig{Hostname: "host"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	s.Handle(log.NewMessage("unix:wrn: over socket", 0))
+
+	b := make([]byte, 1024)
+	l.SetReadDeadline(time.Now().Add(time.Second))
+	n, _, err := l.ReadFrom(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(b[:n]), "<12>1 ") || !strings.HasSuffix(string(b[:n]), " unix "+strconv.Itoa(os.Getpid())+" - - over socket") {
+		t.Fatalf("unexpected message %q", b[:n])
+	}
+}
@@ -0,0 +1,140 @@
+package log
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// TraceID identifies trace, all spans of one operation share it
+type TraceID [16]byte
+
+func (t TraceID) String() string {
+	return hex.EncodeToString(t[:])
+}
+
+// SpanID identifies single span of trace
+type SpanID [8]byte
+
+func (s SpanID) String() string {
+	return hex.EncodeToString(s[:])
+}
+
+// SpanContext identifies span as described by W3C Trace Context, when it is
+// stored in context.Context by ContextWithSpan, trace_id and span_id are
+// attached to every Message logged with that context.
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+
+	// Flags of trace, 1 means sampled
+	Flags byte
+
+	// State is vendor specific tracestate header passed unchanged
+	State string
+}
+
+// ErrTraceparent is returned when traceparent header is malformed
+var ErrTraceparent = errors.New("sokool.log: invalid traceparent")
+
+// ParseTraceparent reads SpanContext from W3C traceparent header value s, ie
+// 00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01
+func ParseTraceparent(s string) (SpanContext, error) {
+	var c SpanContext
+	s = strings.TrimSpace(s)
+	if len(s) < 55 || s[2] != '-' || s[35] != '-' || s[52] != '-' {
+		return c, ErrTraceparent
+	}
+	switch v := s[:2]; {
+	case v == "ff", !lowerHex(v):
+		return c, ErrTraceparent
+	case v == "00" && len(s) != 55, len(s) > 55 && s[55] != '-':
+		return c, ErrTraceparent
+	}
+	var f [1]byte
+	if !lowerHex(s[3:35]) || !lowerHex(s[36:52]) || !lowerHex(s[53:55]) {
+		return c, ErrTraceparent
+	}
+	hex.Decode(c.TraceID[:], []byte(s[3:35]))
+	hex.Decode(c.SpanID[:], []byte(s[36:52]))
+	hex.Decode(f[:], []byte(s[53:55]))
+	if c.Flags = f[0]; !c.IsValid() {
+		return SpanContext{}, ErrTraceparent
+	}
+	return c, nil
+}
+
+// NewSpan returns SpanContext of new span which is child of parent, when
+// parent is not valid new trace is started
+func NewSpan(parent SpanContext) SpanContext {
+	c := parent
+	if !c.IsValid() {
+		c = SpanContext{Flags: 1}
+		rand.Read(c.TraceID[:])
+	}
+	rand.Read(c.SpanID[:])
+	return c
+}
+
+// IsValid tells if both TraceID and SpanID are set
+func (c SpanContext) IsValid() bool {
+	return c.TraceID != TraceID{} && c.SpanID != SpanID{}
+}
+
+// Sampled tells if trace is recorded by caller
+func (c SpanContext) Sampled() bool {
+	return c.Flags&1 != 0
+}
+
+// String returns SpanContext as W3C traceparent header value
+func (c SpanContext) String() string {
+	return "00-" + c.TraceID.String() + "-" + c.SpanID.String() + "-" + hex.EncodeToString([]byte{c.Flags})
+}
+
+// ContextWithSpan returns copy of ctx which carries SpanContext c
+func ContextWithSpan(ctx context.Context, c SpanContext) context.Context {
+	return context.WithValue(ctx, spanKey, c)
+}
+
+// SpanFromContext returns SpanContext stored in ctx by ContextWithSpan
+func SpanFromContext(ctx context.Context) (SpanContext, bool) {
+	c, ok := ctx.Value(spanKey).(SpanContext)
+	return c, ok && c.IsValid()
+}
+
+// Extract returns copy of ctx with SpanContext read from traceparent and
+// tracestate headers h, ctx is returned unchanged when they are missing or
+// malformed
+func Extract(ctx context.Context, h http.Header) context.Context {
+	c, err := ParseTraceparent(h.Get("traceparent"))
+	if err != nil {
+		return ctx
+	}
+	c.State = strings.Join(h.Values("tracestate"), ",")
+	return ContextWithSpan(ctx, c)
+}
+
+// Inject writes SpanContext carried by ctx into traceparent and tracestate
+// headers h
+func Inject(ctx context.Context, h http.Header) {
+	c, ok := SpanFromContext(ctx)
+	if !ok {
+		return
+	}
+	h.Set("traceparent", c.String())
+	if h.Del("tracestate"); c.State != "" {
+		h.Set("tracestate", c.State)
+	}
+}
+
+func lowerHex(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
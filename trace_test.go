@@ -0,0 +1,115 @@
+package log_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sokool/log"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	type scenario struct {
+		input string
+		err   bool
+	}
+	cases := []scenario{
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", false},
+		{" 00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00 ", false},
+		{"01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-future", false},
+		{"01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01future", true},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-", true},
+		{"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", true},
+		{"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01", true},
+		{"00-00000000000000000000000000000000-00f067aa0ba902b7-01", true},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01", true},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7", true},
+		{"", true},
+	}
+	for _, c := range cases {
+		s, err := log.ParseTraceparent(c.input)
+		if c.err {
+			if !errors.Is(err, log.ErrTraceparent) {
+				t.Fatalf("%q: expected ErrTraceparent, got %v", c.input, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%q: %s", c.input, err)
+		}
+		if s.TraceID.String() != "4bf92f3577b34da6a3ce929d0e0e4736" || s.SpanID.String() != "00f067aa0ba902b7" {
+			t.Fatalf("%q: unexpected span %v", c.input, s)
+		}
+		if x := strings.TrimSpace(c.input); x[:2] == "00" && s.String() != x {
+			t.Fatalf("expected %s, got %s", x, s)
+		}
+	}
+}
+
+func TestLogger_TraceContext(t *testing.T) {
+	c, _ := log.ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	ctx := log.ContextWithSpan(context.Background(), c)
+
+	type scenario struct {
+		description string
+		option      log.Option
+		output      string
+	}
+	cases := []scenario{
+		{"json", log.JSON | log.Levels | log.Properties, `{"attr":null,"level":"INFO","span_id":"00f067aa0ba902b7","text":"paid","trace_id":"4bf92f3577b34da6a3ce929d0e0e4736"}` + "\n"},
+		{"text with properties", log.Levels | log.Properties, "[INF] paid span_id=00f067aa0ba902b7 trace_id=4bf92f3577b34da6a3ce929d0e0e4736\n"},
+		{"text", log.Levels, "[INF] paid\n"},
+	}
+	for _, x := range cases {
+		t.Run(x.description, func(t *testing.T) {
+			var b bytes.Buffer
+			log.New(&b, x.option).InfoCtx(ctx, "paid")
+			if b.String() != x.output {
+				t.Fatalf("expected `%s`, got `%s`", x.output, b.String())
+			}
+		})
+	}
+}
+
+func TestPropagation(t *testing.T) {
+	var header http.Header
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Clone()
+	}))
+	defer upstream.Close()
+
+	var b bytes.Buffer
+	l := log.New(&b, log.JSON|log.Tags|log.Properties)
+	c := &http.Client{Transport: log.Transport(nil, l)}
+	h := l.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q, _ := http.NewRequestWithContext(r.Context(), http.MethodGet, upstream.URL, nil)
+		res, err := c.Do(q)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		res.Body.Close()
+	}))
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	r.Header.Set("tracestate", "vendor=value")
+	h.ServeHTTP(httptest.NewRecorder(), r)
+
+	s, err := log.ParseTraceparent(header.Get("traceparent"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.TraceID.String() != "4bf92f3577b34da6a3ce929d0e0e4736" || s.SpanID.String() == "00f067aa0ba902b7" || !s.Sampled() {
+		t.Fatalf("unexpected propagated span %v", s)
+	}
+	if header.Get("tracestate") != "vendor=value" {
+		t.Fatalf("expected tracestate, got %v", header)
+	}
+	if o := b.String(); strings.Count(o, `"trace_id":"4bf92f3577b34da6a3ce929d0e0e4736"`) != 2 || !strings.Contains(o, `"span_id":"`+s.SpanID.String()+`"`) {
+		t.Fatalf("expected correlated messages, got %s", o)
+	}
+}
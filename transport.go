@@ -0,0 +1,200 @@
+package log
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TransportOption configures http.RoundTripper created by Transport
+type TransportOption func(*transport)
+
+// TransportTag changes tag of messages, http:client by default
+func TransportTag(name string) TransportOption {
+	return func(t *transport) { t.tag = name }
+}
+
+// RedactQuery hides values of given query parameters in logged URL, next to
+// token, access_token, api_key, apikey, key, password, secret, signature and
+// sig which are always hidden
+func RedactQuery(keys ...string) TransportOption {
+	return func(t *transport) {
+		for _, k := range keys {
+			t.redacted[strings.ToLower(k)] = true
+		}
+	}
+}
+
+// CaptureHeaders adds request and response headers to messages, values of
+// Authorization, Cookie, Set-Cookie and Proxy-Authorization are hidden
+func CaptureHeaders() TransportOption {
+	return func(t *transport) { t.headers = true }
+}
+
+// CaptureBody adds up to limit bytes of request and response bodies to messages.
+// Response body is captured while caller reads it, so message of request with
+// response body is logged when body is read to the end or closed.
+func CaptureBody(limit int) TransportOption {
+	return func(t *transport) { t.body = limit }
+}
+
+// Transport wraps http.RoundTripper base, http.DefaultTransport when nil, and
+// logs every outbound request with method, URL, status and latency. Successful
+// requests are logged with DEBUG, client errors with WARNING, server and
+// transport errors with ERROR level.
+//
+// When request context carries SpanContext, traceparent header of new child
+// span is added to request and messages are logged with trace_id and span_id.
+func Transport(base http.RoundTripper, lgr *Logger, opts ...TransportOption) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	t := &transport{
+		base:   base,
+		logger: lgr,
+		tag:    "http:client",
+		redacted: map[string]bool{
+			"token": true, "access_token": true, "api_key": true, "apikey": true, "key": true,
+			"password": true, "secret": true, "signature": true, "sig": true,
+		},
+	}
+	for _, o := range opts {
+		o(t)
+	}
+	return t
+}
+
+type transport struct {
+	base     http.RoundTripper
+	logger   *Logger
+	tag      string
+	redacted map[string]bool
+	headers  bool
+	body     int
+}
+
+func (t *transport) RoundTrip(r *http.Request) (*http.Response, error) {
+	req, res := Data{}, Data{}
+	if c, ok := SpanFromContext(r.Context()); ok {
+		r = r.Clone(ContextWithSpan(r.Context(), NewSpan(c)))
+		Inject(r.Context(), r.Header)
+	}
+	lgr := t.logger.context(r.Context())
+	tags := append(lgr.tags(), strings.Split(t.tag, ":")...)
+	if t.headers {
+		req["header"] = t.header(r.Header)
+	}
+	if t.body > 0 && r.Body != nil && r.Body != http.NoBody {
+		b, body := t.capture(r.Body)
+		req["body"], r = b, r.Clone(r.Context())
+		r.Body = body
+	}
+
+	s := time.Now()
+	out, err := t.base.RoundTrip(r)
+	d := Data{"duration": time.Since(s).String()}
+	if len(req) > 0 {
+		d["request"] = req
+	}
+	u := t.redact(r.URL)
+	if err != nil {
+		d["error"] = err.Error()
+		lgr.event(tags, ERROR, "%s %s failed %v", r.Method, u, d)
+		return out, err
+	}
+
+	if t.headers {
+		res["header"] = t.header(out.Header)
+	}
+	var typ = DEBUG
+	switch {
+	case out.StatusCode >= 500:
+		typ = ERROR
+	case out.StatusCode >= 400:
+		typ = WARNING
+	}
+	done := func() {
+		if len(res) > 0 {
+			d["response"] = res
+		}
+		lgr.event(tags, typ, "%s %s %d %v", r.Method, u, out.StatusCode, d)
+	}
+	if t.body > 0 && out.Body != nil && out.Body != http.NoBody {
+		out.Body = &tee{ReadCloser: out.Body, limit: t.body, done: func(b []byte) {
+			res["body"] = string(b)
+			done()
+		}}
+		return out, nil
+	}
+	done()
+	return out, nil
+}
+
+// capture reads up to limit bytes of body and returns them with reader which
+// still delivers whole body
+func (t *transport) capture(body io.ReadCloser) (string, io.ReadCloser) {
+	b, _ := io.ReadAll(io.LimitReader(body, int64(t.body)))
+	return string(b), struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(b), body), body}
+}
+
+// tee captures up to limit bytes of response body while it is read, done is
+// called once when body is read to the end or closed
+type tee struct {
+	io.ReadCloser
+	limit int
+	buf   []byte
+	once  sync.Once
+	done  func([]byte)
+}
+
+func (b *tee) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if k := min(n, b.limit-len(b.buf)); k > 0 {
+		b.buf = append(b.buf, p[:k]...)
+	}
+	if err != nil {
+		b.once.Do(func() { b.done(b.buf) })
+	}
+	return n, err
+}
+
+func (b *tee) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(func() { b.done(b.buf) })
+	return err
+}
+
+func (t *transport) redact(u *url.URL) string {
+	q := u.Query()
+	if len(q) == 0 {
+		return u.Redacted()
+	}
+	for k := range q {
+		if t.redacted[strings.ToLower(k)] {
+			q[k] = []string{"REDACTED"}
+		}
+	}
+	c := *u
+	c.RawQuery = q.Encode()
+	return c.Redacted()
+}
+
+func (t *transport) header(h http.Header) Data {
+	d := Data{}
+	for k, v := range h {
+		switch k {
+		case "Authorization", "Cookie", "Set-Cookie", "Proxy-Authorization":
+			d[k] = "REDACTED"
+		default:
+			d[k] = strings.Join(v, ", ")
+		}
+	}
+	return d
+}
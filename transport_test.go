@@ -0,0 +1,101 @@
+package log_test
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sokool/log"
+)
+
+func TestTransport(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		w.Header().Set("Set-Cookie", "session=secret")
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+		w.Write(append([]byte("echo "), b...))
+	}))
+	defer srv.Close()
+
+	var b bytes.Buffer
+	l := log.New(&b, log.Levels|log.Tags|log.Properties)
+	c := &http.Client{Transport: log.Transport(nil, l, log.RedactQuery("session"), log.CaptureHeaders(), log.CaptureBody(8))}
+
+	res, err := c.Post(srv.URL+"/ok?token=abc&session=xyz&page=2", "text/plain", strings.NewReader("hello world"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body, _ := io.ReadAll(res.Body); string(body) != "echo hello world" {
+		t.Fatalf("body not restored `%s`", body)
+	}
+	r := `^\[DBG\] \[http:client\] POST http://127\.0\.0\.1:\d+/ok\?page=2&session=REDACTED&token=REDACTED 200 duration=\S+ ` +
+		`request\.body="hello wo" request\.header\.Content-Type=text/plain ` +
+		`response\.body="echo hel" response\.header\.Content-Length=16 response\.header\.Content-Type="text/plain; charset=utf-8" response\.header\.Date=".+" response\.header\.Set-Cookie=REDACTED` + "\n$"
+	if !regexp.MustCompile(r).MatchString(b.String()) {
+		t.Fatal(b.String())
+	}
+
+	b.Reset()
+	c = &http.Client{Transport: log.Transport(nil, l, log.TransportTag("github"))}
+	if _, err = c.Get(srv.URL + "/missing"); err != nil {
+		t.Fatal(err)
+	}
+	if !regexp.MustCompile(`^\[WRN\] \[github\] GET http://127\.0\.0\.1:\d+/missing 404 duration=\S+` + "\n$").MatchString(b.String()) {
+		t.Fatal(b.String())
+	}
+
+	b.Reset()
+	if _, err = c.Get("http://127.0.0.1:0/unreachable"); err == nil {
+		t.Fatal("expected error")
+	}
+	if !strings.HasPrefix(b.String(), "[ERR] [github] GET http://127.0.0.1:0/unreachable failed duration=") {
+		t.Fatal(b.String())
+	}
+
+	b.Reset()
+	l = log.New(&b, log.Levels|log.Tags|log.Trace).Tag("app")
+	c = &http.Client{Transport: log.Transport(nil, l, log.TransportTag("my api%s:v1"))}
+	if _, err = c.Get(srv.URL + "/ok"); err != nil {
+		t.Fatal(err)
+	}
+	if !regexp.MustCompile(`^\[DBG\] \[app:my api%s:v1\] GET http://127\.0\.0\.1:\d+/ok 200` + "\n$").MatchString(b.String()) {
+		t.Fatal(b.String())
+	}
+}
+
+func TestTransport_StreamingBody(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("data: first\n\n"))
+		w.(http.Flusher).Flush()
+		<-release
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	var b bytes.Buffer
+	c := &http.Client{Transport: log.Transport(nil, log.New(&b, log.Levels|log.Properties), log.CaptureBody(4))}
+	s := time.Now()
+	res, err := c.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := time.Since(s); d > time.Second {
+		t.Fatalf("response held for %s", d)
+	}
+	if l, _ := bufio.NewReader(res.Body).ReadString('\n'); l != "data: first\n" || b.Len() != 0 {
+		t.Fatalf("unexpected line %q and output `%s`", l, b.String())
+	}
+	res.Body.Close()
+	if !regexp.MustCompile(`^\[DBG\] GET http://127\.0\.0\.1:\d+ 200 duration=\S+ response\.body=data` + "\n$").MatchString(b.String()) {
+		t.Fatal(b.String())
+	}
+}